@@ -32,6 +32,10 @@ func main() {
 		log.Fatalf("backfill slugs: %v", err)
 	}
 
+	if err := db.BackfillTags(context.Background()); err != nil {
+		log.Fatalf("backfill tags: %v", err)
+	}
+
 	botUsername, err := fetchBotUsername(cfg.BotToken)
 	if err != nil {
 		log.Fatalf("telegram bot: %v", err)
@@ -41,26 +45,35 @@ func main() {
 	tgClient := telegram.NewClient(cfg.BotToken)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	tgClient.StartPolling(ctx, func(tgUserID, chatID int64) {
-		user, err := db.GetUserByTgID(context.Background(), tgUserID)
-		if err != nil {
-			log.Printf("poll: user not found for tg_id=%d: %v", tgUserID, err)
-			return
-		}
-		if err := db.SetTgChatID(context.Background(), user.ID, chatID); err != nil {
-			log.Printf("poll: set tg_chat_id: %v", err)
-			return
-		}
-		log.Printf("poll: linked tg_chat_id=%d for user %d", chatID, user.ID)
-		tgClient.SendMessage(chatID, "Уведомления подключены! Теперь вы будете получать сообщения о новых откликах.")
-	})
-
 	h := handler.New(db, "templates", cfg.BotToken, botUsername, cfg.CSRFSecret, cfg.CookieDomain, tgClient, cfg.DevLogin)
 	if cfg.DevLogin {
 		log.Println("Dev login enabled at /auth/dev")
 	}
+
+	bot := telegram.NewBot(tgClient, db, h.DecideResponse)
+	if err := bot.RegisterCommands(); err != nil {
+		log.Printf("telegram commands: %v", err)
+	}
+	if cfg.TelegramWebAppURL != "" {
+		if err := tgClient.SetMenuButton("Открыть", cfg.TelegramWebAppURL); err != nil {
+			log.Printf("telegram menu button: %v", err)
+		}
+	}
+
+	if cfg.TelegramWebhookURL != "" {
+		if err := tgClient.SetWebhook(cfg.TelegramWebhookURL, cfg.TelegramWebhookSecret); err != nil {
+			log.Fatalf("telegram webhook: %v", err)
+		}
+		h.SetTelegramWebhook(cfg.TelegramWebhookPath(), tgClient.WebhookHandler(cfg.TelegramWebhookSecret, bot))
+		log.Printf("Bot webhook: %s", cfg.TelegramWebhookURL)
+	} else {
+		tgClient.StartPolling(ctx, bot)
+	}
+
 	router := h.Router()
 
+	h.StartResponseExpiry(ctx, cfg.ResponseExpiryDays, cfg.ResponseReminderDays)
+
 	go func() {
 		sig := make(chan os.Signal, 1)
 		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
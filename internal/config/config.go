@@ -2,11 +2,18 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// webhookSecretRe matches the secret tokens Telegram accepts for webhooks.
+var webhookSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 type Config struct {
 	BotToken     string
 	DatabasePath string
@@ -15,6 +22,22 @@ type Config struct {
 	CSRFSecret   string
 	CookieDomain string
 	DevLogin     bool
+
+	// ResponseExpiryDays is how long a response may stay pending before it
+	// expires; ResponseReminderDays is how long before that the author is
+	// reminded. A zero ResponseReminderDays turns reminders off.
+	ResponseExpiryDays   int
+	ResponseReminderDays int
+
+	// TelegramWebhookURL switches the bot from long polling to a webhook
+	// when set. TelegramWebhookSecret is the token Telegram sends back with
+	// every update so the endpoint can tell it apart from strangers.
+	TelegramWebhookURL    string
+	TelegramWebhookSecret string
+
+	// TelegramWebAppURL is the Mini App the bot's menu button opens. Empty
+	// leaves the menu button alone.
+	TelegramWebAppURL string
 }
 
 func Load() (*Config, error) {
@@ -46,9 +69,61 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("CSRF_SECRET is required")
 	}
 
+	c.TelegramWebhookURL = os.Getenv("TG_WEBHOOK_URL")
+	c.TelegramWebhookSecret = os.Getenv("TG_WEBHOOK_SECRET")
+	if c.TelegramWebhookURL != "" {
+		u, err := url.Parse(c.TelegramWebhookURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("TG_WEBHOOK_URL must be an https URL")
+		}
+		// The webhook is served on the site's router, so it needs a path of
+		// its own rather than the home page.
+		if strings.Trim(u.Path, "/") == "" {
+			return nil, fmt.Errorf("TG_WEBHOOK_URL must include a path, e.g. https://svyaz.fitra.tech/telegram/webhook")
+		}
+		if !webhookSecretRe.MatchString(c.TelegramWebhookSecret) {
+			return nil, fmt.Errorf("TG_WEBHOOK_SECRET is required with TG_WEBHOOK_URL: 1-256 letters, digits, _ or -")
+		}
+	}
+
+	c.TelegramWebAppURL = os.Getenv("TG_WEBAPP_URL")
+	if c.TelegramWebAppURL != "" {
+		u, err := url.Parse(c.TelegramWebAppURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("TG_WEBAPP_URL must be an https URL")
+		}
+	}
+
+	c.ResponseExpiryDays = 30
+	if v := os.Getenv("RESPONSE_EXPIRY_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			return nil, fmt.Errorf("RESPONSE_EXPIRY_DAYS must be a positive number of days")
+		}
+		c.ResponseExpiryDays = days
+	}
+	c.ResponseReminderDays = 3
+	if v := os.Getenv("RESPONSE_REMINDER_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("RESPONSE_REMINDER_DAYS must be a number of days")
+		}
+		c.ResponseReminderDays = days
+	}
+	if c.ResponseReminderDays >= c.ResponseExpiryDays {
+		return nil, fmt.Errorf("RESPONSE_REMINDER_DAYS must be less than RESPONSE_EXPIRY_DAYS, or 0 to turn reminders off")
+	}
+
 	return c, nil
 }
 
 func (c *Config) Addr() string {
 	return c.Host + ":" + c.Port
 }
+
+// TelegramWebhookPath is the path the webhook is served at on this server.
+// Load makes sure it isn't the root.
+func (c *Config) TelegramWebhookPath() string {
+	u, _ := url.Parse(c.TelegramWebhookURL)
+	return u.Path
+}
@@ -149,6 +149,10 @@ func (h *Handler) handleAdminApproveProject(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if project, err := h.repo.GetProject(r.Context(), id); err == nil {
+		h.alertSavedSearches(r.Context(), project)
+	}
+
 	http.Redirect(w, r, "/projects/"+chi.URLParam(r, "id"), http.StatusFound)
 }
 
@@ -175,6 +179,10 @@ func (h *Handler) handleAdminDeleteProject(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if project, err := h.repo.GetProject(r.Context(), id); err == nil {
+		h.notifySavers(r.Context(), project, "saved_project_deleted")
+	}
+
 	if err := h.repo.DeleteProject(r.Context(), id); err != nil {
 		log.Printf("admin delete project: %v", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
@@ -1,16 +1,24 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 
 	"svyaz/internal/middleware"
+	"svyaz/internal/models"
+	"svyaz/internal/repo"
+	"svyaz/internal/telegram"
 )
 
 func (h *Handler) handleCreateProject(w http.ResponseWriter, r *http.Request) {
@@ -28,8 +36,23 @@ func (h *Handler) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stack := parseTags(r.FormValue("stack"))
+	stack, err := h.repo.NormalizeTags(r.Context(), parseTags(r.FormValue("stack")))
+	if err != nil {
+		log.Printf("normalize stack: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
 	roleCounts := parseRoleCounts(r)
+	questions, errMsg := parseQuestions(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	reasons, errMsg := parseRejectReasons(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
 
 	slug, err := h.repo.CreateProject(r.Context(), user.ID, title, description, stack, roleCounts)
 	if err != nil {
@@ -38,6 +61,28 @@ func (h *Handler) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	project, err := h.repo.GetProjectBySlug(r.Context(), slug)
+	if err != nil {
+		log.Printf("get created project: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.repo.SetProjectQuestions(r.Context(), project.ID, questions); err != nil {
+		log.Printf("set project questions: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.repo.SetProjectAutoClose(r.Context(), project.ID, r.FormValue("auto_close") == "1"); err != nil {
+		log.Printf("set project auto close: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.repo.SetProjectRejectReasons(r.Context(), project.ID, reasons); err != nil {
+		log.Printf("set project reject reasons: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/project/%s?created=1", slug), http.StatusFound)
 }
 
@@ -48,7 +93,7 @@ func (h *Handler) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	if user.ID != project.AuthorID {
+	if !h.can(r.Context(), user, project, permEditProject) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -60,8 +105,23 @@ func (h *Handler) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
 
 	title := strings.TrimSpace(r.FormValue("title"))
 	description := strings.TrimSpace(r.FormValue("description"))
-	stack := parseTags(r.FormValue("stack"))
+	stack, err := h.repo.NormalizeTags(r.Context(), parseTags(r.FormValue("stack")))
+	if err != nil {
+		log.Printf("normalize stack: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
 	roleCounts := parseRoleCounts(r)
+	questions, errMsg := parseQuestions(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	reasons, errMsg := parseRejectReasons(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
 
 	if err := h.repo.UpdateProject(r.Context(), project.ID, title, description, stack, roleCounts); err != nil {
 		log.Printf("update project: %v", err)
@@ -69,6 +129,23 @@ func (h *Handler) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.repo.SetProjectQuestions(r.Context(), project.ID, questions); err != nil {
+		log.Printf("set project questions: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.repo.SetProjectAutoClose(r.Context(), project.ID, r.FormValue("auto_close") == "1"); err != nil {
+		log.Printf("set project auto close: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.repo.SetProjectRejectReasons(r.Context(), project.ID, reasons); err != nil {
+		log.Printf("set project reject reasons: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
 }
 
@@ -79,7 +156,7 @@ func (h *Handler) handleCloseProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	if user.ID != project.AuthorID {
+	if !h.can(r.Context(), user, project, permEditProject) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -90,6 +167,10 @@ func (h *Handler) handleCloseProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !project.IsClosed {
+		h.notifySavers(r.Context(), project, "saved_project_closed")
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
 }
 
@@ -100,11 +181,14 @@ func (h *Handler) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	if user.ID != project.AuthorID {
+	if !h.can(r.Context(), user, project, permDeleteProject) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
 
+	// Bookmarks go away with the project, so savers are notified first.
+	h.notifySavers(r.Context(), project, "saved_project_deleted")
+
 	if err := h.repo.DeleteProject(r.Context(), project.ID); err != nil {
 		log.Printf("delete project: %v", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
@@ -114,6 +198,154 @@ func (h *Handler) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/my/projects", http.StatusFound)
 }
 
+func (h *Handler) handleSaveProject(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if err := h.repo.SaveProject(r.Context(), user.ID, project.ID); err != nil {
+		log.Printf("save project: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+func (h *Handler) handleUnsaveProject(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if err := h.repo.UnsaveProject(r.Context(), user.ID, project.ID); err != nil {
+		log.Printf("unsave project: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.FormValue("from") == "saved" {
+		http.Redirect(w, r, "/my/saved", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+// notifySavers sends an in-app notification to everyone who bookmarked the project.
+func (h *Handler) notifySavers(ctx context.Context, project *models.Project, ntype string) {
+	savers, err := h.repo.ListProjectSavers(ctx, project.ID)
+	if err != nil {
+		log.Printf("list project savers: %v", err)
+		return
+	}
+	for _, uid := range savers {
+		if uid == project.AuthorID {
+			continue
+		}
+		_ = h.repo.CreateNotification(ctx, uid, ntype, map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+		})
+	}
+}
+
+func (h *Handler) handleCreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// The feed posts one stack field per tag, settings a comma-separated list.
+	var stack []string
+	for _, v := range r.Form["stack"] {
+		stack = append(stack, parseTags(v)...)
+	}
+	stack = h.resolveTags(r, stack)
+	roles := nonEmpty(r.Form["role"])
+	q := strings.TrimSpace(r.FormValue("q"))
+	// A query of only punctuation matches everything, so it doesn't count.
+	if !repo.HasSearchTerms(q) {
+		q = ""
+	}
+
+	if len(roles) == 0 && len(stack) == 0 && q == "" {
+		http.Error(w, "Выберите роли, стек или ключевые слова", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.repo.CountSavedSearches(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("count saved searches: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if count >= repo.MaxSavedSearches {
+		http.Error(w, fmt.Sprintf("Можно сохранить не больше %d поисков", repo.MaxSavedSearches), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.CreateSavedSearch(r.Context(), user.ID, roles, stack, q); err != nil {
+		log.Printf("create saved search: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/settings#saved-searches", http.StatusFound)
+}
+
+func (h *Handler) handleDeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if err := h.repo.DeleteSavedSearch(r.Context(), id, user.ID); err != nil {
+		log.Printf("delete saved search: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/settings#saved-searches", http.StatusFound)
+}
+
+// alertSavedSearches sends a Telegram message to users whose saved searches
+// match a freshly approved project. Each user hears about a project once.
+func (h *Handler) alertSavedSearches(ctx context.Context, project *models.Project) {
+	if h.tgClient == nil {
+		return
+	}
+
+	userIDs, err := h.repo.MatchSavedSearches(ctx, project)
+	if err != nil {
+		log.Printf("match saved searches: %v", err)
+		return
+	}
+
+	link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+	text := fmt.Sprintf("Новый проект по вашему поиску: <b>%s</b>\n%s", project.Title, link)
+	for _, uid := range userIDs {
+		u, err := h.repo.GetUser(ctx, uid)
+		if err != nil || u.TgChatID == 0 {
+			continue
+		}
+		fresh, err := h.repo.MarkSavedSearchAlerted(ctx, uid, project.ID)
+		if err != nil {
+			log.Printf("mark saved search alerted: %v", err)
+			continue
+		}
+		if fresh {
+			go h.tgClient.SendMessage(u.TgChatID, text)
+		}
+	}
+}
+
 func (h *Handler) handleRespond(w http.ResponseWriter, r *http.Request) {
 	project := h.projectBySlug(w, r)
 	if project == nil {
@@ -121,7 +353,7 @@ func (h *Handler) handleRespond(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	if user.ID == project.AuthorID {
+	if h.can(r.Context(), user, project, permManageResponses) {
 		http.Error(w, "Нельзя откликнуться на свой проект", http.StatusBadRequest)
 		return
 	}
@@ -131,11 +363,6 @@ func (h *Handler) handleRespond(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if already, _ := h.repo.HasUserResponded(r.Context(), project.ID, user.ID); already {
-		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
-		return
-	}
-
 	_ = r.ParseForm()
 	var roleID *int64
 	if rid := r.FormValue("role_id"); rid != "" {
@@ -154,66 +381,205 @@ func (h *Handler) handleRespond(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.repo.CreateResponse(r.Context(), project.ID, user.ID, roleID); err != nil {
+	if already, _ := h.repo.HasUserResponded(r.Context(), project.ID, user.ID, roleID); already {
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
+	}
+
+	if roleID != nil {
+		roles, err := h.repo.GetProjectRolesWithFilled(r.Context(), project.ID)
+		if err != nil {
+			log.Printf("get project roles: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		for _, role := range roles {
+			if role.ID == *roleID && role.Filled >= role.Count {
+				http.Error(w, "На эту роль уже набрали людей", http.StatusConflict)
+				return
+			}
+		}
+	}
+
+	message, links, errMsg := parseResponseMessage(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	questions, err := h.repo.ListProjectQuestions(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("list project questions: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	answers, errMsg := parseAnswers(r, questions)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	responseID, err := h.repo.CreateResponse(r.Context(), project.ID, user.ID, roleID, message, links, answers)
+	if err == repo.ErrInvalidTransition {
+		// Another request got the response in first.
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
+	}
+	if err != nil {
 		log.Printf("create response: %v", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
 		return
 	}
 
-	_ = h.repo.CreateNotification(r.Context(), project.AuthorID, "new_response", map[string]any{
+	link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+	text := fmt.Sprintf("Новый отклик от <b>%s</b> на \"%s\"\n", user.Name, project.Title)
+	if message != "" {
+		text += "\n" + html.EscapeString(message) + "\n"
+	}
+	for _, l := range links {
+		text += "\n" + html.EscapeString(l)
+	}
+	if len(links) > 0 {
+		text += "\n"
+	}
+	text += "\n" + link
+	manageLink := fmt.Sprintf("https://svyaz.fitra.tech/project/%s/responses", project.Slug)
+	h.notifyManagersWithKeyboard(r.Context(), project, user.ID, "new_response", map[string]any{
 		"project_id":    project.ID,
 		"project_slug":  project.Slug,
 		"project_title": project.Title,
 		"user_name":     user.Name,
 		"user_id":       user.ID,
-	})
-
-	if h.tgClient != nil && project.Author != nil && project.Author.TgChatID > 0 {
-		link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
-		text := fmt.Sprintf("Новый отклик от <b>%s</b> на \"%s\"\n%s", user.Name, project.Title, link)
-		go h.tgClient.SendMessage(project.Author.TgChatID, text)
-	}
+	}, text, telegram.ResponseDecisionKeyboard(responseID, manageLink))
 
 	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
 }
 
-func (h *Handler) handleCancelResponse(w http.ResponseWriter, r *http.Request) {
-	project := h.projectBySlug(w, r)
-	if project == nil {
-		return
+// Limits for the cover letter attached to a response.
+const (
+	maxResponseMessage = 1000
+	maxResponseLinks   = 3
+)
+
+// parseResponseMessage reads the optional cover letter and portfolio links
+// (one per line) from the respond form. A non-empty errMsg is user-facing.
+func parseResponseMessage(r *http.Request) (message string, links []string, errMsg string) {
+	message = strings.TrimSpace(r.FormValue("message"))
+	if utf8.RuneCountInString(message) > maxResponseMessage {
+		return "", nil, fmt.Sprintf("Сообщение длиннее %d символов", maxResponseMessage)
 	}
 
-	user := middleware.UserFromContext(r.Context())
+	for _, l := range strings.Fields(r.FormValue("links")) {
+		u, err := url.Parse(l)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(l) > 300 {
+			return "", nil, fmt.Sprintf("Некорректная ссылка: %s", l)
+		}
+		links = append(links, l)
+	}
+	if len(links) > maxResponseLinks {
+		return "", nil, fmt.Sprintf("Можно добавить не больше %d ссылок", maxResponseLinks)
+	}
+	return message, links, ""
+}
 
-	resp, err := h.repo.GetUserResponseForProject(r.Context(), project.ID, user.ID)
-	if err != nil {
-		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
-		return
+const (
+	maxQuestionPrompt = 200
+	maxAnswerLength   = 500
+)
+
+// parseQuestions reads the screening questions editor from the project form.
+// Rows come as parallel q_* fields; rows left without a prompt are dropped.
+func parseQuestions(r *http.Request) ([]models.Question, string) {
+	ids, kinds, prompts := r.Form["q_id"], r.Form["q_kind"], r.Form["q_prompt"]
+	options, required := r.Form["q_options"], r.Form["q_required"]
+	at := func(ss []string, i int) string {
+		if i < len(ss) {
+			return ss[i]
+		}
+		return ""
 	}
 
-	if resp.Status != "pending" {
-		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
-		return
+	var questions []models.Question
+	for i, prompt := range prompts {
+		prompt = strings.TrimSpace(prompt)
+		if prompt == "" {
+			continue
+		}
+		if utf8.RuneCountInString(prompt) > maxQuestionPrompt {
+			return nil, fmt.Sprintf("Вопрос длиннее %d символов", maxQuestionPrompt)
+		}
+
+		q := models.Question{
+			Kind:     at(kinds, i),
+			Prompt:   prompt,
+			Required: at(required, i) == "1",
+		}
+		q.ID, _ = strconv.ParseInt(at(ids, i), 10, 64)
+
+		switch q.Kind {
+		case models.QuestionChoice:
+			q.Options = parseTags(at(options, i))
+			if len(q.Options) < 2 {
+				return nil, fmt.Sprintf("У вопроса «%s» должно быть хотя бы два варианта ответа", prompt)
+			}
+		case models.QuestionYesNo:
+		default:
+			q.Kind = models.QuestionText
+		}
+		questions = append(questions, q)
 	}
 
-	if err := h.repo.DeleteResponse(r.Context(), resp.ID, user.ID); err != nil {
-		log.Printf("cancel response: %v", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
-		return
+	if len(questions) > repo.MaxProjectQuestions {
+		return nil, fmt.Sprintf("Можно задать не больше %d вопросов", repo.MaxProjectQuestions)
 	}
+	return questions, ""
+}
 
-	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+// parseAnswers validates the applicant's answers to the project's questions,
+// submitted as answer_<question id>.
+func parseAnswers(r *http.Request, questions []models.Question) ([]models.Answer, string) {
+	var answers []models.Answer
+	for _, q := range questions {
+		value := strings.TrimSpace(r.FormValue(fmt.Sprintf("answer_%d", q.ID)))
+		if value == "" {
+			if q.Required {
+				return nil, fmt.Sprintf("Ответьте на вопрос «%s»", q.Prompt)
+			}
+			continue
+		}
+
+		switch q.Kind {
+		case models.QuestionChoice:
+			if !hasString(q.Options, value) {
+				return nil, fmt.Sprintf("Выберите один из вариантов в вопросе «%s»", q.Prompt)
+			}
+		case models.QuestionYesNo:
+			if value != "yes" && value != "no" {
+				return nil, fmt.Sprintf("Ответьте «да» или «нет» на вопрос «%s»", q.Prompt)
+			}
+			value = map[string]string{"yes": "Да", "no": "Нет"}[value]
+		default:
+			if utf8.RuneCountInString(value) > maxAnswerLength {
+				return nil, fmt.Sprintf("Ответ на вопрос «%s» длиннее %d символов", q.Prompt, maxAnswerLength)
+			}
+		}
+
+		id := q.ID
+		answers = append(answers, models.Answer{QuestionID: &id, Prompt: q.Prompt, Answer: value})
+	}
+	return answers, ""
 }
 
-func (h *Handler) handleUpdateResponse(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) handleEditResponse(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
+	user := middleware.UserFromContext(r.Context())
 	resp, err := h.repo.GetResponse(r.Context(), id)
-	if err != nil {
+	if err != nil || resp.UserID != user.ID {
 		http.NotFound(w, r)
 		return
 	}
@@ -224,63 +590,650 @@ func (h *Handler) handleUpdateResponse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := middleware.UserFromContext(r.Context())
-	if user.ID != project.AuthorID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+	if resp.Status != "pending" {
+		http.Error(w, "Отклик уже рассмотрен", http.StatusConflict)
 		return
 	}
 
-	_ = r.ParseForm()
-	status := r.FormValue("status")
-	if status != "accepted" && status != "rejected" {
-		http.Error(w, "Invalid status", http.StatusBadRequest)
+	message, links, errMsg := parseResponseMessage(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
 		return
 	}
 
-	if err := h.repo.UpdateResponseStatus(r.Context(), id, status); err != nil {
-		log.Printf("update response: %v", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
+	if err := h.repo.UpdateResponseMessage(r.Context(), resp.ID, user.ID, message, links); err != nil {
+		http.Error(w, "Отклик уже рассмотрен", http.StatusConflict)
 		return
 	}
 
-	if status == "accepted" {
-		_ = h.repo.CreateNotification(r.Context(), resp.UserID, "response_accepted", map[string]any{
-			"project_id":    project.ID,
-			"project_slug":  project.Slug,
-			"project_title": project.Title,
-		})
-
-		if h.tgClient != nil {
-			respUser, err := h.repo.GetUser(r.Context(), resp.UserID)
-			if err == nil && respUser.TgChatID > 0 {
-				link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
-				text := fmt.Sprintf("Ваш отклик на \"%s\" принят!\n%s", project.Title, link)
-				go h.tgClient.SendMessage(respUser.TgChatID, text)
-			}
-		}
-	}
-
 	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
 }
 
-func (h *Handler) handleSaveOnboarding(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+func (h *Handler) handleCancelResponse(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
 		return
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	name := strings.TrimSpace(r.FormValue("name"))
-	if name == "" {
-		name = user.Name
+
+	// Each role applied for is a separate response, so the form says which
+	// one to withdraw.
+	id, _ := strconv.ParseInt(r.FormValue("response_id"), 10, 64)
+	resp, err := h.repo.GetResponse(r.Context(), id)
+	if err != nil || resp.UserID != user.ID || resp.ProjectID != project.ID {
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
 	}
 
-	bio := strings.TrimSpace(r.FormValue("bio"))
-	experience := r.FormValue("experience")
-	skills := parseTags(r.FormValue("skills"))
-	roleIDs := parseIntSlice(r.Form["roles"])
+	err = h.repo.UpdateResponseStatus(r.Context(), resp.ID, user.ID, models.ResponseWithdrawn)
+	if err != nil && err != repo.ErrInvalidTransition {
+		log.Printf("withdraw response: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+// handleLeaveTeam lets an accepted member leave the team, freeing their seat.
+func (h *Handler) handleLeaveTeam(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+
+	id, _ := strconv.ParseInt(r.FormValue("response_id"), 10, 64)
+	resp, err := h.repo.GetResponse(r.Context(), id)
+	if err != nil || resp.UserID != user.ID || resp.ProjectID != project.ID {
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
+	}
+
+	err = h.repo.UpdateResponseStatus(r.Context(), resp.ID, user.ID, models.ResponseLeft)
+	if err == repo.ErrInvalidTransition {
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
+	}
+	if err != nil {
+		log.Printf("leave team: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	payload := map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"user_id":       user.ID,
+		"user_name":     user.Name,
+	}
+	var roleName string
+	for _, role := range project.Roles {
+		if resp.RoleID != nil && role.ID == *resp.RoleID {
+			roleName = role.Name
+			payload["role_name"] = roleName
+		}
+	}
+	link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+	text := fmt.Sprintf("%s покинул(а) команду \"%s\"", user.Name, project.Title)
+	if roleName != "" {
+		text += fmt.Sprintf(" (%s)", roleName)
+	}
+	text += ". Место снова свободно.\n" + link
+	h.notifyManagers(r.Context(), project, user.ID, "member_left", payload, text)
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+func (h *Handler) handleUpdateResponse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	resp, err := h.repo.GetResponse(r.Context(), id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	project, err := h.repo.GetProject(r.Context(), resp.ProjectID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if !h.can(r.Context(), user, project, permManageResponses) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	_ = r.ParseForm()
+	status := r.FormValue("status")
+	if status == models.ResponseWithdrawn || status == models.ResponsePending || status == models.ResponseLeft {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	var reason string
+	if status == models.ResponseRejected || status == models.ResponseRemoved {
+		var errMsg string
+		if reason, errMsg = parseRejectReason(r, project); errMsg != "" {
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.applyResponseStatus(r.Context(), project, resp, user, status, reason); err != nil {
+		if err == repo.ErrInvalidTransition {
+			http.Error(w, "Отклик нельзя перевести в этот статус", http.StatusConflict)
+			return
+		}
+		if err == errResponseOffLimits {
+			http.Error(w, "Этот отклик вы изменить не можете", http.StatusForbidden)
+			return
+		}
+		if err == repo.ErrRoleFull {
+			http.Error(w, "Все места на эту роль уже заняты", http.StatusConflict)
+			return
+		}
+		log.Printf("update response: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+// DecideResponse accepts or rejects a response for the bot's inline buttons,
+// with the same checks and notifications as handleUpdateResponse.
+func (h *Handler) DecideResponse(ctx context.Context, actor *models.User, responseID int64, status string) (string, bool) {
+	resp, err := h.repo.GetResponse(ctx, responseID)
+	if err != nil {
+		return "Отклик не найден.", false
+	}
+	project, err := h.repo.GetProject(ctx, resp.ProjectID)
+	if err != nil {
+		return "Проект не найден.", false
+	}
+	if !h.can(ctx, actor, project, permManageResponses) {
+		return "Только автор проекта и его помощники могут отвечать на отклики.", false
+	}
+
+	if err := h.applyResponseStatus(ctx, project, resp, actor, status, ""); err != nil {
+		if err == repo.ErrInvalidTransition {
+			return fmt.Sprintf("Отклик уже обработан, статус: %s.", models.ResponseStatusText(resp.Status)), false
+		}
+		if err == errResponseOffLimits {
+			return "Решение по своему отклику принимает автор проекта.", false
+		}
+		if err == repo.ErrRoleFull {
+			return "Все места на эту роль уже заняты.", false
+		}
+		log.Printf("update response: %v", err)
+		return "Что-то пошло не так, попробуйте позже.", false
+	}
+	return fmt.Sprintf("%s (%s)", models.ResponseStatusText(status), actor.Name), true
+}
+
+// handleBulkUpdateResponses applies one status to the responses the author
+// ticked on the management page. Responses that can't make the transition
+// are skipped and counted rather than failing the whole batch.
+func (h *Handler) handleBulkUpdateResponses(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if !h.can(r.Context(), user, project, permManageResponses) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	_ = r.ParseForm()
+	status := r.FormValue("status")
+	// Every status the author can set is reachable from pending.
+	if status == models.ResponseWithdrawn || !repo.CanTransition(models.ResponsePending, status) {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	var reason string
+	if status == models.ResponseRejected {
+		var errMsg string
+		if reason, errMsg = parseRejectReason(r, project); errMsg != "" {
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var updated, skipped int
+	for _, v := range r.Form["ids"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		resp, err := h.repo.GetResponse(r.Context(), id)
+		if err != nil || resp.ProjectID != project.ID {
+			continue
+		}
+
+		err = h.applyResponseStatus(r.Context(), project, resp, user, status, reason)
+		if err == repo.ErrInvalidTransition || err == repo.ErrRoleFull || err == errResponseOffLimits {
+			skipped++
+			continue
+		}
+		if err != nil {
+			log.Printf("bulk update response %d: %v", id, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		updated++
+	}
+
+	q := url.Values{}
+	if v := r.FormValue("filter_role"); v != "" {
+		q.Set("role", v)
+	}
+	if v := r.FormValue("filter_status"); v != "" {
+		q.Set("status", v)
+	}
+	q.Set("updated", strconv.Itoa(updated))
+	if skipped > 0 {
+		q.Set("skipped", strconv.Itoa(skipped))
+	}
+	http.Redirect(w, r, fmt.Sprintf("/project/%s/responses?%s", project.Slug, q.Encode()), http.StatusFound)
+}
+
+// errResponseOffLimits is returned by applyResponseStatus when the actor
+// manages responses but may not touch this one.
+var errResponseOffLimits = errors.New("response is off limits to the actor")
+
+// applyResponseStatus moves the author's response to a new status and lets
+// the applicant know about it. Nobody decides on their own response, and only
+// those who may manage members can remove someone who helps run the project.
+func (h *Handler) applyResponseStatus(ctx context.Context, project *models.Project, resp *models.Response, actor *models.User, status, reason string) error {
+	if resp.UserID == actor.ID {
+		return errResponseOffLimits
+	}
+	if status == models.ResponseRemoved && !h.can(ctx, actor, project, permManageMembers) {
+		role, err := h.repo.GetProjectMemberRole(ctx, project.ID, resp.UserID)
+		if err != nil {
+			return err
+		}
+		if role != "" || resp.UserID == project.AuthorID {
+			return errResponseOffLimits
+		}
+	}
+
+	actorID := actor.ID
+	var err error
+	switch status {
+	case models.ResponseRejected:
+		err = h.repo.RejectResponse(ctx, resp.ID, actorID, reason)
+	case models.ResponseRemoved:
+		err = h.repo.RemoveMember(ctx, resp.ID, actorID, reason)
+	default:
+		err = h.repo.UpdateResponseStatus(ctx, resp.ID, actorID, status)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.ResponseShortlisted, models.ResponseInterview:
+		_ = h.repo.CreateNotification(ctx, resp.UserID, "response_status", map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+			"status":        status,
+		})
+	case models.ResponseAccepted:
+		h.afterAccept(ctx, project, resp.RoleID)
+		_ = h.repo.CreateNotification(ctx, resp.UserID, "response_accepted", map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+		})
+
+		if h.tgClient != nil {
+			respUser, err := h.repo.GetUser(ctx, resp.UserID)
+			if err == nil && respUser.TgChatID > 0 {
+				link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+				text := fmt.Sprintf("Ваш отклик на \"%s\" принят!\n%s", project.Title, link)
+				go h.tgClient.SendMessage(respUser.TgChatID, text)
+			}
+		}
+	case models.ResponseRejected:
+		_ = h.repo.CreateNotification(ctx, resp.UserID, "response_rejected", map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+			"reason":        reason,
+		})
+
+		if h.tgClient != nil {
+			respUser, err := h.repo.GetUser(ctx, resp.UserID)
+			if err == nil && respUser.TgChatID > 0 && respUser.NotifyRejected {
+				link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+				text := fmt.Sprintf("Ваш отклик на \"%s\" отклонён.\n", project.Title)
+				if reason != "" {
+					text += "Причина: " + html.EscapeString(reason) + "\n"
+				}
+				text += link
+				go h.tgClient.SendMessage(respUser.TgChatID, text)
+			}
+		}
+	case models.ResponseRemoved:
+		_ = h.repo.CreateNotification(ctx, resp.UserID, "member_removed", map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+			"reason":        reason,
+		})
 
-	if err := h.repo.UpdateUserProfile(r.Context(), user.ID, name, bio, experience, skills, roleIDs); err != nil {
+		if h.tgClient != nil {
+			respUser, err := h.repo.GetUser(ctx, resp.UserID)
+			if err == nil && respUser.TgChatID > 0 {
+				link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+				text := fmt.Sprintf("Автор проекта \"%s\" исключил вас из команды.\n", project.Title)
+				if reason != "" {
+					text += "Причина: " + html.EscapeString(reason) + "\n"
+				}
+				text += link
+				go h.tgClient.SendMessage(respUser.TgChatID, text)
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultRejectReasons are offered when the author hasn't set up their own.
+var defaultRejectReasons = []string{
+	"Уже нашли человека на эту роль",
+	"Не хватает опыта",
+	"Не подходит стек",
+	"Проект пока на паузе",
+}
+
+const (
+	maxRejectReason  = 300
+	maxRejectReasons = 10
+)
+
+// rejectReasons returns the canned reasons the author can pick from.
+func rejectReasons(project *models.Project) []string {
+	if len(project.RejectReasons) > 0 {
+		return project.RejectReasons
+	}
+	return defaultRejectReasons
+}
+
+// parseRejectReason reads the reason from the reject form: the author's own
+// text wins over a picked canned reason. Both are optional.
+func parseRejectReason(r *http.Request, project *models.Project) (string, string) {
+	if text := strings.TrimSpace(r.FormValue("reason_text")); text != "" {
+		if utf8.RuneCountInString(text) > maxRejectReason {
+			return "", fmt.Sprintf("Причина длиннее %d символов", maxRejectReason)
+		}
+		return text, ""
+	}
+	reason := r.FormValue("reason")
+	if reason != "" && !hasString(rejectReasons(project), reason) {
+		return "", "Неизвестная причина отказа"
+	}
+	return reason, ""
+}
+
+// parseRejectReasons reads the project's canned reasons, one per line.
+func parseRejectReasons(r *http.Request) ([]string, string) {
+	var reasons []string
+	for _, line := range strings.Split(r.FormValue("reject_reasons"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxRejectReason {
+			return nil, fmt.Sprintf("Причина отказа длиннее %d символов", maxRejectReason)
+		}
+		reasons = append(reasons, line)
+	}
+	if len(reasons) > maxRejectReasons {
+		return nil, fmt.Sprintf("Можно задать не больше %d причин отказа", maxRejectReasons)
+	}
+	return reasons, ""
+}
+
+// afterAccept follows up on someone joining a project role: applicants still
+// waiting on a role that is now full are told so, and savers hear about it if
+// the project closed itself.
+func (h *Handler) afterAccept(ctx context.Context, project *models.Project, roleID *int64) {
+	if roleID != nil {
+		roles, err := h.repo.GetProjectRolesWithFilled(ctx, project.ID)
+		if err != nil {
+			log.Printf("get project roles: %v", err)
+		}
+		for _, role := range roles {
+			if role.ID != *roleID || role.Filled < role.Count {
+				continue
+			}
+			applicants, err := h.repo.ListOpenApplicants(ctx, project.ID, role.ID)
+			if err != nil {
+				log.Printf("list open applicants: %v", err)
+			}
+			for _, uid := range applicants {
+				_ = h.repo.CreateNotification(ctx, uid, "role_filled", map[string]any{
+					"project_id":    project.ID,
+					"project_slug":  project.Slug,
+					"project_title": project.Title,
+					"role_name":     role.Name,
+				})
+			}
+		}
+	}
+
+	if !project.IsClosed {
+		if updated, err := h.repo.GetProject(ctx, project.ID); err == nil && updated.IsClosed {
+			h.notifySavers(ctx, updated, "saved_project_closed")
+		}
+	}
+}
+
+func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
+	inviteeID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	invitee, err := h.repo.GetUser(r.Context(), inviteeID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if invitee.ID == user.ID || !invitee.Onboarded || invitee.IsBanned {
+		http.Error(w, "Этого пользователя нельзя пригласить", http.StatusBadRequest)
+		return
+	}
+
+	// target is "<project id>:<role id>" from the grouped role select.
+	projectPart, rolePart, _ := strings.Cut(r.FormValue("target"), ":")
+	projectID, err1 := strconv.ParseInt(projectPart, 10, 64)
+	roleID, err2 := strconv.ParseInt(rolePart, 10, 64)
+	if err1 != nil || err2 != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.repo.GetProject(r.Context(), projectID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.can(r.Context(), user, project, permManageResponses) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if project.Status != "active" || project.IsClosed {
+		http.Error(w, "Набор в проект закрыт", http.StatusBadRequest)
+		return
+	}
+
+	var role *models.Role
+	for i := range project.Roles {
+		if project.Roles[i].ID == roleID {
+			role = &project.Roles[i]
+		}
+	}
+	if role == nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if already, _ := h.repo.HasUserResponded(r.Context(), project.ID, invitee.ID, &role.ID); already {
+		http.Error(w, "Пользователь уже откликнулся на эту роль", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.CreateInvitation(r.Context(), project.ID, role.ID, invitee.ID, user.ID); err != nil {
+		switch err {
+		case repo.ErrInvitationPending:
+			http.Error(w, "Приглашение в этот проект уже отправлено", http.StatusBadRequest)
+		case repo.ErrInvitationDeclined:
+			http.Error(w, "Пользователь уже отклонил приглашение в этот проект", http.StatusBadRequest)
+		case repo.ErrInvitationLimit:
+			http.Error(w, fmt.Sprintf("Можно отправить не больше %d приглашений в сутки", repo.InvitationDailyLimit), http.StatusTooManyRequests)
+		default:
+			log.Printf("create invitation: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_ = h.repo.CreateNotification(r.Context(), invitee.ID, "invitation", map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"role_name":     role.Name,
+		"user_name":     user.Name,
+		"user_id":       user.ID,
+	})
+
+	if h.tgClient != nil && invitee.TgChatID > 0 {
+		text := fmt.Sprintf("<b>%s</b> приглашает вас в проект \"%s\" на роль %s\nhttps://svyaz.fitra.tech/my/responses",
+			user.Name, project.Title, role.Name)
+		go h.tgClient.SendMessage(invitee.TgChatID, text)
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/user/%d", invitee.ID), http.StatusFound)
+}
+
+func (h *Handler) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	h.answerInvitation(w, r, true)
+}
+
+func (h *Handler) handleDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	h.answerInvitation(w, r, false)
+}
+
+func (h *Handler) answerInvitation(w http.ResponseWriter, r *http.Request, accept bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	inv, err := h.repo.GetInvitation(r.Context(), id)
+	if err != nil || inv.UserID != user.ID {
+		http.NotFound(w, r)
+		return
+	}
+
+	project, err := h.repo.GetProject(r.Context(), inv.ProjectID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if accept {
+		err = h.repo.AcceptInvitation(r.Context(), inv.ID, user.ID)
+	} else {
+		err = h.repo.DeclineInvitation(r.Context(), inv.ID, user.ID)
+	}
+	if err == repo.ErrInvitationClosed || err == repo.ErrInvalidTransition {
+		http.Error(w, "Приглашение уже неактуально", http.StatusConflict)
+		return
+	}
+	if err == repo.ErrProjectClosed {
+		http.Error(w, "Набор в проект закрыт", http.StatusConflict)
+		return
+	}
+	if err == repo.ErrRoleFull {
+		http.Error(w, "Все места на эту роль уже заняты", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("answer invitation: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	ntype, tgText := "invitation_declined", ""
+	if accept {
+		ntype = "invitation_accepted"
+		link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+		tgText = fmt.Sprintf("<b>%s</b> принял(а) приглашение в \"%s\"\n%s", user.Name, project.Title, link)
+	}
+	h.notifyManagers(r.Context(), project, user.ID, ntype, map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"user_name":     user.Name,
+		"user_id":       user.ID,
+	}, tgText)
+
+	if accept {
+		h.afterAccept(r.Context(), project, &inv.RoleID)
+		http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/my/responses", http.StatusFound)
+}
+
+func (h *Handler) handleSaveOnboarding(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		name = user.Name
+	}
+
+	bio := strings.TrimSpace(r.FormValue("bio"))
+	experience := r.FormValue("experience")
+	skills, err := h.repo.NormalizeTags(r.Context(), parseTags(r.FormValue("skills")))
+	if err != nil {
+		log.Printf("normalize skills: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	roleIDs := parseIntSlice(r.Form["roles"])
+
+	isPublic := r.FormValue("is_public") == "1"
+
+	if err := h.repo.UpdateUserProfile(r.Context(), user.ID, name, bio, experience, skills, roleIDs, isPublic); err != nil {
 		log.Printf("update profile: %v", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
 		return
@@ -293,6 +1246,16 @@ func (h *Handler) handleSaveProfile(w http.ResponseWriter, r *http.Request) {
 	h.handleSaveOnboarding(w, r)
 }
 
+func (h *Handler) handleSaveNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	if err := h.repo.SetNotifyRejected(r.Context(), user.ID, r.FormValue("notify_rejected") == "1"); err != nil {
+		log.Printf("save notification settings: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/settings", http.StatusFound)
+}
+
 func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	notifs, err := h.repo.ListNotifications(r.Context(), user.ID, 20)
@@ -314,6 +1277,26 @@ func (h *Handler) handleMarkNotificationsRead(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *Handler) handleTagSuggest(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	var tags []string
+	if q != "" {
+		var err error
+		tags, err = h.repo.SearchTags(r.Context(), q, 8)
+		if err != nil {
+			log.Printf("search tags: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if tags == nil {
+		_, _ = w.Write([]byte("[]"))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(tags)
+}
+
 func parseTags(s string) []string {
 	var tags []string
 	for _, t := range strings.Split(s, ",") {
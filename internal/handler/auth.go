@@ -2,20 +2,26 @@ package handler
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"svyaz/internal/middleware"
 	"svyaz/internal/repo"
+	"svyaz/internal/telegram"
 )
 
 func (h *Handler) handleDevLogin(w http.ResponseWriter, r *http.Request) {
@@ -114,14 +120,32 @@ func (h *Handler) handleTelegramAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := repo.GenerateToken()
-	if err := h.repo.CreateSession(r.Context(), token, user.ID); err != nil {
+	if err := h.startSession(w, r, user.ID, http.SameSiteLaxMode); err != nil {
 		log.Printf("create session error: %v", err)
 		http.Error(w, "Internal error", 500)
 		return
 	}
 
+	if isNew || !user.Onboarded {
+		http.Redirect(w, r, "/onboarding", http.StatusFound)
+	} else {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+// startSession logs the browser in as the user. sameSite is Lax for the site
+// itself; the Mini App needs None, see handleWebAppAuth.
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, userID int64, sameSite http.SameSite) error {
+	token := repo.GenerateToken()
+	if err := h.repo.CreateSession(r.Context(), token, userID); err != nil {
+		return err
+	}
+
 	secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+	// Browsers drop SameSite=None cookies that aren't Secure.
+	if sameSite == http.SameSiteNoneMode {
+		secure = true
+	}
 
 	// Clear old host-only cookie (no Domain) to avoid conflicts with new domain cookie
 	if h.cookieDomain != "" {
@@ -141,18 +165,170 @@ func (h *Handler) handleTelegramAuth(w http.ResponseWriter, r *http.Request) {
 		HttpOnly: true,
 		Secure:   secure,
 		MaxAge:   30 * 24 * 3600,
-		SameSite: http.SameSiteLaxMode,
+		SameSite: sameSite,
 	}
 	if h.cookieDomain != "" {
 		cookie.Domain = h.cookieDomain
 	}
 	http.SetCookie(w, cookie)
+	return nil
+}
 
-	if isNew || !user.Onboarded {
-		http.Redirect(w, r, "/onboarding", http.StatusFound)
-	} else {
+// handleBotLogin starts a login confirmed in the bot, for people whose
+// browser can't show the Telegram login widget. The browser keeps the login
+// token in a cookie and polls handleBotLoginCheck until the user confirms.
+func (h *Handler) handleBotLogin(w http.ResponseWriter, r *http.Request) {
+	if middleware.UserFromContext(r.Context()) != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("bot login token: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(b)
+	if err := h.repo.CreateBotLogin(r.Context(), token, clientIP(r), r.UserAgent()); err != nil {
+		log.Printf("create bot login: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "bot_login",
+		Value:    token,
+		Path:     "/auth/bot",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		MaxAge:   int(repo.BotLoginTTL.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.render(w, r, "bot_login.html", map[string]any{
+		"BotLink": h.botStartLink(telegram.StartLogin, token),
+	})
+}
+
+// handleBotLoginCheck logs the browser in once the login it started was
+// confirmed in the bot. It answers {"ok": false} until then, adding
+// "expired": true once the login can't be confirmed anymore.
+func (h *Handler) handleBotLoginCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	cookie, err := r.Cookie("bot_login")
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "expired": true})
+		return
+	}
+	userID, err := h.repo.TakeBotLogin(r.Context(), cookie.Value)
+	if err == repo.ErrBotLoginExpired {
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "expired": true})
+		return
+	}
+	if err != nil {
+		log.Printf("take bot login: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if userID == 0 {
+		json.NewEncoder(w).Encode(map[string]any{"ok": false})
+		return
+	}
+
+	user, err := h.repo.GetUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("bot login user: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.startSession(w, r, user.ID, http.SameSiteLaxMode); err != nil {
+		log.Printf("create session error: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "bot_login", Value: "", Path: "/auth/bot", HttpOnly: true, MaxAge: -1})
+
+	redirect := "/"
+	if !user.Onboarded {
+		redirect = "/onboarding"
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "redirect": redirect})
+}
+
+// clientIP returns the address of the browser. The site runs behind a
+// reverse proxy, which puts it first in X-Forwarded-For.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		return strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// handleWebApp is the entry point of the Telegram Mini App opened from the
+// bot's menu button. The page logs in with the data Telegram hands to the web
+// app, since the login widget doesn't work inside Telegram.
+func (h *Handler) handleWebApp(w http.ResponseWriter, r *http.Request) {
+	if middleware.UserFromContext(r.Context()) != nil {
 		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	h.render(w, r, "webapp.html", nil)
+}
+
+// handleWebAppAuth logs in with the Mini App's initData. On Telegram Web the
+// app runs in a cross-site iframe, where browsers only send SameSite=None
+// cookies, so the session cookie is set that way.
+func (h *Handler) handleWebAppAuth(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.validateWebAppInitData(r.FormValue("init_data"))
+	if !ok {
+		http.Error(w, "Ошибка авторизации", http.StatusForbidden)
+		return
+	}
+
+	var tgUser struct {
+		ID        int64  `json:"id"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Username  string `json:"username"`
+		PhotoURL  string `json:"photo_url"`
+	}
+	if err := json.Unmarshal([]byte(data.Get("user")), &tgUser); err != nil || tgUser.ID == 0 {
+		http.Error(w, "Invalid user", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(tgUser.FirstName + " " + tgUser.LastName)
+	user, isNew, err := h.repo.UpsertUser(r.Context(), tgUser.ID, tgUser.Username, name, tgUser.PhotoURL)
+	if err != nil {
+		log.Printf("upsert user error: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.startSession(w, r, user.ID, http.SameSiteNoneMode); err != nil {
+		log.Printf("create session error: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	redirect := "/"
+	if isNew || !user.Onboarded {
+		redirect = "/onboarding"
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "redirect": redirect})
+}
+
+// botStartLink returns a signed link that opens the bot with action.
+func (h *Handler) botStartLink(action string, arg any) string {
+	return telegram.StartLink(h.botUsername, h.botToken, action, fmt.Sprint(arg))
 }
 
 func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
@@ -226,3 +402,44 @@ func (h *Handler) validateTelegramAuth(query url.Values) bool {
 
 	return true
 }
+
+// validateWebAppInitData checks the initData Telegram passes to a Mini App.
+// Unlike the login widget, the key is an HMAC of the bot token keyed with
+// "WebAppData" rather than its SHA-256.
+func (h *Handler) validateWebAppInitData(initData string) (url.Values, bool) {
+	data, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, false
+	}
+	hash := data.Get("hash")
+	if hash == "" {
+		return nil, false
+	}
+
+	params := make([]string, 0, len(data))
+	for key, values := range data {
+		if key == "hash" {
+			continue
+		}
+		params = append(params, key+"="+values[0])
+	}
+	sort.Strings(params)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(h.botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(params, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(hash), []byte(expected)) {
+		return nil, false
+	}
+
+	// Reject init data older than 1 day
+	authDate, err := strconv.ParseInt(data.Get("auth_date"), 10, 64)
+	if err != nil || math.Abs(float64(time.Now().Unix()-authDate)) > 86400 {
+		return nil, false
+	}
+
+	return data, true
+}
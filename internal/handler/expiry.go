@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"svyaz/internal/models"
+)
+
+// responseExpiryInterval is how often the expiry job looks for stale responses.
+const responseExpiryInterval = time.Hour
+
+// StartResponseExpiry runs the response expiry job in a background goroutine
+// until ctx is cancelled. Pending responses older than days, or on projects
+// that closed recruitment, are expired; authors are reminded remindDays
+// before their responses expire. A remindDays of zero turns reminders off;
+// otherwise it must be less than days.
+func (h *Handler) StartResponseExpiry(ctx context.Context, days, remindDays int) {
+	go func() {
+		ticker := time.NewTicker(responseExpiryInterval)
+		defer ticker.Stop()
+		for {
+			h.runResponseExpiry(ctx, days, remindDays)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *Handler) runResponseExpiry(ctx context.Context, days, remindDays int) {
+	expired, err := h.repo.ExpireResponses(ctx, days)
+	if err != nil {
+		log.Printf("expire responses: %v", err)
+		return
+	}
+	projects := make(map[int64]*models.Project)
+	for _, resp := range expired {
+		project := h.cachedProject(ctx, projects, resp.ProjectID)
+		if project == nil {
+			continue
+		}
+		h.notifyResponseExpired(ctx, project, resp, days)
+	}
+
+	if remindDays <= 0 {
+		return
+	}
+	expiring, err := h.repo.TakeExpiringResponses(ctx, days, remindDays)
+	if err != nil {
+		log.Printf("take expiring responses: %v", err)
+		return
+	}
+	counts := make(map[int64]int)
+	for _, resp := range expiring {
+		counts[resp.ProjectID]++
+	}
+	for projectID, count := range counts {
+		project := h.cachedProject(ctx, projects, projectID)
+		if project == nil {
+			continue
+		}
+		h.remindResponsesExpiring(ctx, project, count, remindDays)
+	}
+}
+
+func (h *Handler) cachedProject(ctx context.Context, cache map[int64]*models.Project, id int64) *models.Project {
+	if project, ok := cache[id]; ok {
+		return project
+	}
+	project, err := h.repo.GetProject(ctx, id)
+	if err != nil {
+		log.Printf("expiry: get project %d: %v", id, err)
+	}
+	cache[id] = project
+	return project
+}
+
+func (h *Handler) notifyResponseExpired(ctx context.Context, project *models.Project, resp models.Response, days int) {
+	_ = h.repo.CreateNotification(ctx, resp.UserID, "response_expired", map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"closed":        project.IsClosed,
+	})
+
+	if h.tgClient == nil {
+		return
+	}
+	// An expired response is as much bad news as a rejection, so it follows
+	// the same opt-out.
+	user, err := h.repo.GetUser(ctx, resp.UserID)
+	if err != nil || user.TgChatID == 0 || !user.NotifyRejected {
+		return
+	}
+	var text string
+	if project.IsClosed {
+		text = fmt.Sprintf("Набор в проект \"%s\" закрыт, ваш отклик больше не рассматривается.\n", project.Title)
+	} else {
+		text = fmt.Sprintf("Автор проекта \"%s\" не ответил на ваш отклик за %d дн., отклик закрыт.\n", project.Title, days)
+	}
+	text += "https://svyaz.fitra.tech/my/responses"
+	go h.tgClient.SendMessage(user.TgChatID, text)
+}
+
+func (h *Handler) remindResponsesExpiring(ctx context.Context, project *models.Project, count, remindDays int) {
+	link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s/responses?status=pending", project.Slug)
+	text := fmt.Sprintf("Откликов без ответа в \"%s\": %d. Через %d дн. они закроются автоматически.\n%s", project.Title, count, remindDays, link)
+	h.notifyManagers(ctx, project, 0, "responses_expiring", map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"count":         count,
+		"days":          remindDays,
+	}, text)
+}
@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
@@ -30,6 +31,11 @@ type Handler struct {
 	cookieDomain string
 	tgClient     *telegram.Client
 	devLogin     bool
+
+	// tgWebhook receives bot updates at tgWebhookPath when the bot runs in
+	// webhook mode; it is nil when the bot polls.
+	tgWebhookPath string
+	tgWebhook     http.Handler
 }
 
 func New(r *repo.Repo, tmplDir, botToken, botUsername, csrfSecret, cookieDomain string, tgClient *telegram.Client, devLogin bool) *Handler {
@@ -45,6 +51,13 @@ func New(r *repo.Repo, tmplDir, botToken, botUsername, csrfSecret, cookieDomain
 	}
 }
 
+// SetTelegramWebhook serves the bot's webhook at path on the main site. It
+// must be called before Router.
+func (h *Handler) SetTelegramWebhook(path string, hook http.Handler) {
+	h.tgWebhookPath = path
+	h.tgWebhook = hook
+}
+
 func (h *Handler) Router() http.Handler {
 	main := h.mainRouter()
 	admin := h.adminRouter()
@@ -75,22 +88,36 @@ func (h *Handler) mainRouter() chi.Router {
 	r.Get("/project/{slug}", h.handleProjectView)
 	r.Get("/project/{slug}/og.png", h.handleOGImage)
 	r.Get("/project/{slug}/edit", h.requireAuth(h.handleProjectEdit))
+	r.Get("/project/{slug}/responses", h.requireAuth(h.handleProjectResponses))
+	r.Get("/project/{slug}/responses.csv", h.requireAuth(h.handleExportResponses))
+	r.Get("/stack/{tag}", h.handleStackPage)
 	r.Get("/user/{id}", h.handleUserProfile)
+	r.Get("/people", h.handlePeople)
 	r.Get("/onboarding", h.requireAuth(h.handleOnboarding))
 	r.Get("/privacy", h.handlePrivacy)
 	r.Get("/consent", h.handleConsent)
 	r.Get("/settings", h.requireAuth(h.handleSettings))
 	r.Get("/my/projects", h.requireAuth(h.handleMyProjects))
 	r.Get("/my/responses", h.requireAuth(h.handleMyResponses))
+	r.Get("/my/saved", h.requireAuth(h.handleMySaved))
 
 	// Auth
 	r.Get("/auth/telegram", h.handleTelegramAuth)
+	r.Get("/auth/bot", h.handleBotLogin)
+	r.Get("/auth/bot/check", h.handleBotLoginCheck)
+	r.Get("/webapp", h.handleWebApp)
+	r.Post("/auth/webapp", h.handleWebAppAuth)
 	r.Post("/auth/logout", h.handleLogout)
 	if h.devLogin {
 		r.Get("/auth/dev", h.handleDevLogin)
 		r.Get("/auth/dev/{id}", h.handleDevLoginAs)
 	}
 
+	// Telegram bot updates; the webhook checks Telegram's secret itself.
+	if h.tgWebhook != nil {
+		r.Method(http.MethodPost, h.tgWebhookPath, h.tgWebhook)
+	}
+
 	// API
 	r.Route("/api", func(r chi.Router) {
 		r.Use(h.csrfMiddleware)
@@ -99,11 +126,26 @@ func (h *Handler) mainRouter() chi.Router {
 		r.Post("/projects/{slug}", h.requireAuth(h.handleUpdateProject))
 		r.Post("/projects/{slug}/delete", h.requireAuth(h.handleDeleteProject))
 		r.Post("/projects/{slug}/close", h.requireAuth(h.handleCloseProject))
+		r.Post("/projects/{slug}/save", h.requireAuth(h.handleSaveProject))
+		r.Post("/projects/{slug}/unsave", h.requireAuth(h.handleUnsaveProject))
 		r.Post("/projects/{slug}/respond", h.requireAuth(h.handleRespond))
 		r.Post("/projects/{slug}/cancel-response", h.requireAuth(h.handleCancelResponse))
+		r.Post("/projects/{slug}/leave", h.requireAuth(h.handleLeaveTeam))
+		r.Post("/projects/{slug}/maintainers", h.requireAuth(h.handleAddMaintainer))
+		r.Post("/projects/{slug}/maintainers/remove", h.requireAuth(h.handleRemoveMaintainer))
+		r.Post("/projects/{slug}/transfer", h.requireAuth(h.handleTransferOwnership))
+		r.Post("/projects/{slug}/responses", h.requireAuth(h.handleBulkUpdateResponses))
 		r.Post("/responses/{id}", h.requireAuth(h.handleUpdateResponse))
+		r.Post("/responses/{id}/message", h.requireAuth(h.handleEditResponse))
+		r.Post("/users/{id}/invite", h.requireAuth(h.handleInviteUser))
+		r.Post("/invitations/{id}/accept", h.requireAuth(h.handleAcceptInvitation))
+		r.Post("/invitations/{id}/decline", h.requireAuth(h.handleDeclineInvitation))
 		r.Post("/user/onboarding", h.requireAuth(h.handleSaveOnboarding))
 		r.Post("/user/profile", h.requireAuth(h.handleSaveProfile))
+		r.Post("/user/notifications", h.requireAuth(h.handleSaveNotificationSettings))
+		r.Post("/saved-searches", h.requireAuth(h.handleCreateSavedSearch))
+		r.Post("/saved-searches/{id}/delete", h.requireAuth(h.handleDeleteSavedSearch))
+		r.Get("/tags", h.handleTagSuggest)
 		r.Get("/notifications", h.requireAuth(h.handleGetNotifications))
 		r.Post("/notifications/read", h.requireAuth(h.handleMarkNotificationsRead))
 	})
@@ -182,6 +224,14 @@ func (h *Handler) csrfMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// tgLink returns a link that opens a chat with the user in Telegram.
+func tgLink(u *models.User) string {
+	if u.TgUsername != "" {
+		return "https://t.me/" + u.TgUsername
+	}
+	return fmt.Sprintf("tg://user?id=%d", u.TgID)
+}
+
 func (h *Handler) render(w http.ResponseWriter, r *http.Request, page string, data map[string]any) {
 	funcMap := template.FuncMap{
 		"formatDate": func(t time.Time) string {
@@ -198,19 +248,30 @@ func (h *Handler) render(w http.ResponseWriter, r *http.Request, page string, da
 			}
 			return string(runes[:n]) + "..."
 		},
-		"join": strings.Join,
-		"statusText": func(s string) string {
+		"join":       strings.Join,
+		"pathEscape": url.PathEscape,
+		"highlight": func(s string) template.HTML {
+			s = template.HTMLEscapeString(s)
+			s = strings.ReplaceAll(s, repo.SnippetOpen, "<mark>")
+			s = strings.ReplaceAll(s, repo.SnippetClose, "</mark>")
+			return template.HTML(s)
+		},
+		"statusText": models.ResponseStatusText,
+		"invitationStatusText": func(s string) string {
 			m := map[string]string{
-				"pending":  "На рассмотрении",
-				"accepted": "Принят",
-				"rejected": "Отклонён",
+				"pending":  "Ждёт ответа",
+				"accepted": "Принято",
+				"declined": "Отклонено",
+				"expired":  "Истекло",
 			}
 			if v, ok := m[s]; ok {
 				return v
 			}
 			return s
 		},
-		"statusClass": func(s string) string { return s },
+		"statusClass":   func(s string) string { return s },
+		"canTransition": repo.CanTransition,
+		"rejectReasons": rejectReasons,
 		"plural": func(n int, one, few, many string) string {
 			if n%10 == 1 && n%100 != 11 {
 				return fmt.Sprintf("%d %s", n, one)
@@ -246,12 +307,7 @@ func (h *Handler) render(w http.ResponseWriter, r *http.Request, page string, da
 			}
 			return string(runes[start:end])
 		},
-		"tgLink": func(u *models.User) string {
-			if u.TgUsername != "" {
-				return "https://t.me/" + u.TgUsername
-			}
-			return fmt.Sprintf("tg://user?id=%d", u.TgID)
-		},
+		"tgLink": tgLink,
 		"tgDisplay": func(u *models.User) string {
 			if u.TgUsername != "" {
 				return "@" + u.TgUsername
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"svyaz/internal/middleware"
+	"svyaz/internal/models"
+	"svyaz/internal/telegram"
+)
+
+// permission is something a project member may be allowed to do.
+type permission int
+
+const (
+	// permEditProject covers editing the project and opening or closing
+	// recruitment.
+	permEditProject permission = iota
+	// permManageResponses covers moving responses along the pipeline and
+	// inviting people.
+	permManageResponses
+	permDeleteProject
+	// permManageMembers covers adding and removing maintainers and handing
+	// the project over to someone else.
+	permManageMembers
+)
+
+// memberPermissions lists what each project member role may do.
+var memberPermissions = map[string][]permission{
+	models.ProjectOwner:      {permEditProject, permManageResponses, permDeleteProject, permManageMembers},
+	models.ProjectMaintainer: {permEditProject, permManageResponses},
+}
+
+// can reports whether the user may do p on the project. A nil user can't do
+// anything.
+func (h *Handler) can(ctx context.Context, user *models.User, project *models.Project, p permission) bool {
+	if user == nil {
+		return false
+	}
+	role := models.ProjectOwner
+	if user.ID != project.AuthorID {
+		var err error
+		if role, err = h.repo.GetProjectMemberRole(ctx, project.ID, user.ID); err != nil {
+			log.Printf("get project member role: %v", err)
+			return false
+		}
+	}
+	for _, allowed := range memberPermissions[role] {
+		if allowed == p {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyManagers sends a notification to everyone running the project, and
+// the Telegram message tgText to those who connected the bot. An empty
+// tgText sends only the in-app notification. skipID is left out, so people
+// aren't told about what they did themselves.
+func (h *Handler) notifyManagers(ctx context.Context, project *models.Project, skipID int64, ntype string, payload map[string]any, tgText string) {
+	h.notifyManagersWithKeyboard(ctx, project, skipID, ntype, payload, tgText, nil)
+}
+
+// notifyManagersWithKeyboard is notifyManagers with inline buttons under the
+// Telegram message.
+func (h *Handler) notifyManagersWithKeyboard(ctx context.Context, project *models.Project, skipID int64, ntype string, payload map[string]any, tgText string, kb telegram.InlineKeyboard) {
+	members, err := h.repo.ListProjectMembers(ctx, project.ID)
+	if err != nil {
+		log.Printf("list project members: %v", err)
+		return
+	}
+	for _, m := range members {
+		if m.UserID == skipID {
+			continue
+		}
+		_ = h.repo.CreateNotification(ctx, m.UserID, ntype, payload)
+		if h.tgClient != nil && tgText != "" && m.User.TgChatID > 0 {
+			go h.tgClient.SendMessageWithKeyboard(m.User.TgChatID, tgText, kb)
+		}
+	}
+}
+
+// teamMemberIDs returns the IDs of the project's accepted team members.
+func (h *Handler) teamMemberIDs(ctx context.Context, projectID int64) (map[int64]bool, error) {
+	team, err := h.repo.ListProjectTeam(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool)
+	for _, m := range team {
+		ids[m.UserID] = true
+	}
+	return ids, nil
+}
+
+func (h *Handler) handleAddMaintainer(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if !h.can(r.Context(), user, project, permManageMembers) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teamMemberIDs(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("list project team: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if !team[userID] {
+		http.Error(w, "Помогать с проектом могут только участники команды", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.AddProjectMaintainer(r.Context(), project.ID, userID); err != nil {
+		log.Printf("add maintainer: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	_ = h.repo.CreateNotification(r.Context(), userID, "maintainer_added", map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"user_name":     user.Name,
+	})
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+// handleRemoveMaintainer takes a maintainer's rights away. The owner can
+// remove anyone; a maintainer can only step down themselves.
+func (h *Handler) handleRemoveMaintainer(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if userID != user.ID && !h.can(r.Context(), user, project, permManageMembers) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.repo.RemoveProjectMaintainer(r.Context(), project.ID, userID); err != nil {
+		log.Printf("remove maintainer: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if userID != user.ID {
+		_ = h.repo.CreateNotification(r.Context(), userID, "maintainer_removed", map[string]any{
+			"project_id":    project.ID,
+			"project_slug":  project.Slug,
+			"project_title": project.Title,
+		})
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
+
+// handleTransferOwnership hands the project over to one of its maintainers.
+func (h *Handler) handleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if !h.can(r.Context(), user, project, permManageMembers) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	role, err := h.repo.GetProjectMemberRole(r.Context(), project.ID, userID)
+	if err != nil {
+		log.Printf("get project member role: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if role != models.ProjectMaintainer {
+		http.Error(w, "Передать проект можно только одному из помощников", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.TransferProjectOwnership(r.Context(), project.ID, userID); err != nil {
+		log.Printf("transfer ownership: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	_ = h.repo.CreateNotification(r.Context(), userID, "ownership_transferred", map[string]any{
+		"project_id":    project.ID,
+		"project_slug":  project.Slug,
+		"project_title": project.Title,
+		"user_name":     user.Name,
+	})
+
+	if h.tgClient != nil {
+		owner, err := h.repo.GetUser(r.Context(), userID)
+		if err == nil && owner.TgChatID > 0 {
+			link := fmt.Sprintf("https://svyaz.fitra.tech/project/%s", project.Slug)
+			text := fmt.Sprintf("<b>%s</b> передал(а) вам проект \"%s\"\n%s", user.Name, project.Title, link)
+			go h.tgClient.SendMessage(owner.TgChatID, text)
+		}
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/project/%s", project.Slug), http.StatusFound)
+}
@@ -1,14 +1,20 @@
 package handler
 
 import (
+	"encoding/csv"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
 	"svyaz/internal/middleware"
 	"svyaz/internal/models"
 	"svyaz/internal/repo"
+	"svyaz/internal/telegram"
 )
 
 func (h *Handler) projectBySlug(w http.ResponseWriter, r *http.Request) *models.Project {
@@ -25,30 +31,405 @@ func (h *Handler) projectBySlug(w http.ResponseWriter, r *http.Request) *models.
 	return project
 }
 
+const feedPageSize = 30
+
+// filterOption is a toggleable filter on a listing page; URL applies or
+// removes it while keeping the rest of the current filters.
+type filterOption struct {
+	Value  string
+	Label  string
+	Count  int
+	Active bool
+	URL    string
+}
+
+// listURL builds a link to a paginated listing from params, always starting
+// from the first page since changing filters invalidates the cursor.
+func listURL(path string, params url.Values) string {
+	params.Del("after")
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + params.Encode()
+}
+
+func cloneParams(params url.Values) url.Values {
+	c := make(url.Values, len(params))
+	for k, v := range params {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// toggleURL returns the listing link with value added to or removed from key.
+func toggleURL(path string, params url.Values, key, value string) string {
+	c := cloneParams(params)
+	var kept []string
+	found := false
+	for _, v := range c[key] {
+		if v == value {
+			found = true
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if !found {
+		kept = append(kept, value)
+	}
+	c[key] = kept
+	if len(kept) == 0 {
+		c.Del(key)
+	}
+	return listURL(path, c)
+}
+
+// withoutURL returns the listing link with keys removed.
+func withoutURL(path string, params url.Values, keys ...string) string {
+	c := cloneParams(params)
+	for _, k := range keys {
+		c.Del(k)
+	}
+	return listURL(path, c)
+}
+
+// flagURL returns the listing link with a boolean "1" parameter switched.
+func flagURL(path string, params url.Values, key string, on bool) string {
+	c := cloneParams(params)
+	if on {
+		c.Set(key, "1")
+	} else {
+		c.Del(key)
+	}
+	return listURL(path, c)
+}
+
+func nonEmpty(ss []string) []string {
+	var out []string
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type sortOption struct {
+	Value string
+	Label string
+}
+
+var feedSortOptions = []sortOption{
+	{repo.SortNewest, "Сначала новые"},
+	{repo.SortUpdated, "Недавно обновлённые"},
+	{repo.SortSeats, "Больше свободных мест"},
+	{repo.SortQuiet, "Мало откликов"},
+	{repo.SortPopular, "Много откликов"},
+}
+
 func (h *Handler) handleIndex(w http.ResponseWriter, r *http.Request) {
-	roleSlug := r.URL.Query().Get("role")
-	stack := r.URL.Query().Get("stack")
+	query := r.URL.Query()
+	if query.Get("tab") == "for-you" && middleware.UserFromContext(r.Context()) != nil {
+		h.handleForYou(w, r)
+		return
+	}
+	q := strings.TrimSpace(query.Get("q"))
+	after := query.Get("after")
 
-	projects, err := h.repo.ListProjects(r.Context(), repo.ProjectFilter{
-		RoleSlug: roleSlug,
-		Stack:    stack,
-		Limit:    50,
-	})
+	// The chosen sort is remembered per user and used when the URL has none;
+	// searches without an explicit sort are ranked by relevance.
+	user := middleware.UserFromContext(r.Context())
+	sort := query.Get("sort")
+	if !repo.ValidSort(sort) {
+		sort = ""
+	}
+	if user != nil {
+		if sort == "" && q == "" {
+			sort = user.FeedSort
+		} else if sort != "" && sort != user.FeedSort {
+			if err := h.repo.SetFeedSort(r.Context(), user.ID, sort); err != nil {
+				log.Printf("save feed sort: %v", err)
+			}
+		}
+	}
+	if sort == repo.SortRelevance && q == "" {
+		sort = ""
+	}
+
+	filter := repo.ProjectFilter{
+		RoleSlugs: nonEmpty(query["role"]),
+		AllRoles:  query.Get("roles_all") == "1",
+		Stack:     h.resolveTags(r, nonEmpty(query["stack"])),
+		AllStack:  query.Get("stack_all") == "1",
+		OpenOnly:  query.Get("open") == "1",
+		HasSeats:  query.Get("seats") == "1",
+		Query:     q,
+		Sort:      sort,
+		After:     after,
+		Limit:     feedPageSize,
+	}
+
+	// Links built below toggle canonical tag names, so the query must hold them too.
+	if len(filter.Stack) > 0 {
+		query["stack"] = filter.Stack
+	}
+
+	projects, cursor, err := h.repo.ListProjects(r.Context(), filter)
+	if err == repo.ErrBadCursor {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
+		log.Printf("list projects: %v", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
 		return
 	}
 
+	roleCounts, tagCounts, err := h.repo.ProjectFacets(r.Context(), filter)
+	if err != nil {
+		log.Printf("project facets: %v", err)
+	}
+
 	roles, _ := h.repo.GetAllRoles(r.Context())
 
+	roleOptions := make([]filterOption, 0, len(roles))
+	for _, role := range roles {
+		active := hasString(filter.RoleSlugs, role.Slug)
+		roleOptions = append(roleOptions, filterOption{
+			Value:  role.Slug,
+			Label:  role.Name,
+			Count:  roleCounts[role.Slug],
+			Active: active,
+			URL:    toggleURL("/", query, "role", role.Slug),
+		})
+	}
+
+	// Selected tags stay visible even when they fell out of the top facets.
+	var tagOptions []filterOption
+	for _, tag := range filter.Stack {
+		count := 0
+		for _, tc := range tagCounts {
+			if tc.Value == tag {
+				count = tc.Count
+			}
+		}
+		tagOptions = append(tagOptions, filterOption{Value: tag, Label: tag, Count: count, Active: true, URL: toggleURL("/", query, "stack", tag)})
+	}
+	for _, tc := range tagCounts {
+		if hasString(filter.Stack, tc.Value) {
+			continue
+		}
+		tagOptions = append(tagOptions, filterOption{Value: tc.Value, Label: tc.Value, Count: tc.Count, URL: toggleURL("/", query, "stack", tc.Value)})
+	}
+
+	var nextPage string
+	if cursor != "" {
+		next := cloneParams(query)
+		next.Set("after", cursor)
+		if sort != "" {
+			next.Set("sort", sort)
+		}
+		nextPage = "/?" + next.Encode()
+	}
+
 	h.render(w, r, "index.html", map[string]any{
-		"Projects":    projects,
-		"Roles":       roles,
-		"FilterRole":  roleSlug,
-		"FilterStack": stack,
+		"Projects":      projects,
+		"RoleOptions":   roleOptions,
+		"TagOptions":    tagOptions,
+		"Filter":        filter,
+		"AllRolesURL":   withoutURL("/", query, "role", "roles_all"),
+		"RolesModeURL":  flagURL("/", query, "roles_all", !filter.AllRoles),
+		"StackModeURL":  flagURL("/", query, "stack_all", !filter.AllStack),
+		"OpenOnlyURL":   flagURL("/", query, "open", !filter.OpenOnly),
+		"HasSeatsURL":   flagURL("/", query, "seats", !filter.HasSeats),
+		"ClearQueryURL": withoutURL("/", query, "q"),
+		"Query":         q,
+		"Sort":          sort,
+		"SortOptions":   feedSortOptions,
+		"HasFilters":    len(filter.RoleSlugs) > 0 || len(filter.Stack) > 0 || filter.OpenOnly || filter.HasSeats || q != "",
+		"IsFirstPage":   after == "",
+		"FirstPage":     listURL("/", cloneParams(query)),
+		"NextPage":      nextPage,
 	})
 }
 
+// handleForYou renders the "Для вас" tab of the index: projects scored
+// against the user's roles and skills.
+func (h *Handler) handleForYou(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	recs, err := h.repo.RecommendProjects(r.Context(), user, feedPageSize)
+	if err != nil {
+		log.Printf("recommend projects: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.render(w, r, "for_you.html", map[string]any{
+		"Recommendations": recs,
+		"ForYou":          true,
+		"HasProfile":      len(user.Roles) > 0 || len(user.Skills) > 0,
+	})
+}
+
+// resolveTags maps filter values to canonical tag names so "golang" or "GO"
+// in a link finds projects tagged "Go".
+func (h *Handler) resolveTags(r *http.Request, tags []string) []string {
+	for i, t := range tags {
+		tags[i], _ = h.repo.ResolveTag(r.Context(), t)
+	}
+	return tags
+}
+
+func (h *Handler) handleStackPage(w http.ResponseWriter, r *http.Request) {
+	raw := chi.URLParam(r, "tag")
+	// chi hands out decoded params, except when the path has escapes like
+	// %2F that make it route on the raw path instead.
+	if r.URL.RawPath != "" {
+		if unescaped, err := url.PathUnescape(raw); err == nil {
+			raw = unescaped
+		}
+	}
+
+	tag, known := h.repo.ResolveTag(r.Context(), raw)
+	if known && tag != raw {
+		http.Redirect(w, r, "/stack/"+url.PathEscape(tag), http.StatusMovedPermanently)
+		return
+	}
+
+	projects, _, err := h.repo.ListProjects(r.Context(), repo.ProjectFilter{
+		Stack: []string{tag},
+		Limit: 50,
+	})
+	if err != nil {
+		log.Printf("list projects by tag: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	people, err := h.repo.ListUsersWithSkill(r.Context(), tag, 50)
+	if err != nil {
+		log.Printf("list users by tag: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Tags outside the catalogue only get a page while someone uses them.
+	if !known && len(projects) == 0 && len(people) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.render(w, r, "stack.html", map[string]any{
+		"Tag":      tag,
+		"Projects": projects,
+		"People":   people,
+	})
+}
+
+func (h *Handler) handlePeople(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	after := query.Get("after")
+
+	var experience []string
+	for _, e := range query["exp"] {
+		if hasString(repo.ExperienceLevels, e) {
+			experience = append(experience, e)
+		}
+	}
+
+	// The skill box uses tag autocomplete, which may leave a trailing comma.
+	var skills []string
+	for _, v := range query["skill"] {
+		skills = append(skills, parseTags(v)...)
+	}
+
+	filter := repo.PeopleFilter{
+		RoleSlugs:  nonEmpty(query["role"]),
+		Skills:     h.resolveTags(r, skills),
+		Experience: experience,
+		After:      after,
+		Limit:      feedPageSize,
+	}
+	// Links below toggle the cleaned values, so the query must hold them too.
+	query["skill"] = filter.Skills
+	query["exp"] = filter.Experience
+	for _, k := range []string{"skill", "exp"} {
+		if len(query[k]) == 0 {
+			query.Del(k)
+		}
+	}
+
+	people, cursor, err := h.repo.ListPeople(r.Context(), filter)
+	if err == repo.ErrBadCursor {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("list people: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	roles, _ := h.repo.GetAllRoles(r.Context())
+	roleOptions := make([]filterOption, 0, len(roles))
+	for _, role := range roles {
+		roleOptions = append(roleOptions, filterOption{
+			Value:  role.Slug,
+			Label:  role.Name,
+			Active: hasString(filter.RoleSlugs, role.Slug),
+			URL:    toggleURL("/people", query, "role", role.Slug),
+		})
+	}
+
+	expOptions := make([]filterOption, 0, len(repo.ExperienceLevels))
+	for _, e := range repo.ExperienceLevels {
+		expOptions = append(expOptions, filterOption{
+			Value:  e,
+			Label:  strings.ToUpper(e[:1]) + e[1:],
+			Active: hasString(filter.Experience, e),
+			URL:    toggleURL("/people", query, "exp", e),
+		})
+	}
+
+	skillOptions := make([]filterOption, 0, len(filter.Skills))
+	for _, skill := range filter.Skills {
+		skillOptions = append(skillOptions, filterOption{
+			Value:  skill,
+			Label:  skill,
+			Active: true,
+			URL:    toggleURL("/people", query, "skill", skill),
+		})
+	}
+
+	var nextPage string
+	if cursor != "" {
+		next := cloneParams(query)
+		next.Set("after", cursor)
+		nextPage = "/people?" + next.Encode()
+	}
+
+	h.render(w, r, "people.html", map[string]any{
+		"People":       people,
+		"RoleOptions":  roleOptions,
+		"ExpOptions":   expOptions,
+		"SkillOptions": skillOptions,
+		"Filter":       filter,
+		"AllRolesURL":  withoutURL("/people", query, "role"),
+		"HasFilters":   len(filter.RoleSlugs) > 0 || len(filter.Skills) > 0 || len(filter.Experience) > 0,
+		"IsFirstPage":  after == "",
+		"FirstPage":    listURL("/people", cloneParams(query)),
+		"NextPage":     nextPage,
+	})
+}
+
+func hasString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Handler) handleProjectView(w http.ResponseWriter, r *http.Request) {
 	project := h.projectBySlug(w, r)
 	if project == nil {
@@ -56,12 +437,12 @@ func (h *Handler) handleProjectView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
+	canManage := h.can(r.Context(), user, project, permEditProject)
 
-	// Non-active projects: only visible to author and admins
+	// Non-active projects: only visible to the people running them and admins
 	if project.Status != "active" {
-		isAuthor := user != nil && user.ID == project.AuthorID
 		isAdmin := user != nil && user.IsAdmin
-		if !isAuthor && !isAdmin {
+		if !canManage && !isAdmin {
 			http.NotFound(w, r)
 			return
 		}
@@ -76,32 +457,261 @@ func (h *Handler) handleProjectView(w http.ResponseWriter, r *http.Request) {
 	data := map[string]any{
 		"Project":     project,
 		"JustCreated": r.URL.Query().Get("created") == "1",
+		"BotLink":     h.botStartLink(telegram.StartProject, project.ID),
 	}
 
+	team, err := h.repo.ListProjectTeam(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("list project team: %v", err)
+	}
+	data["Team"] = groupTeam(team)
+
 	if user != nil {
-		data["IsAuthor"] = user.ID == project.AuthorID
+		data["CanManage"] = canManage
+		data["IsSaved"], _ = h.repo.IsProjectSaved(r.Context(), user.ID, project.ID)
+
+		if !canManage {
+			responses, err := h.repo.GetUserResponsesForProject(r.Context(), project.ID, user.ID)
+			if err != nil {
+				log.Printf("get user responses for project: %v", err)
+			}
+			data["UserResponses"] = responses
+
+			// Roles the user already applied for, by status, so the picker
+			// can show where each application stands. Responses in a final
+			// status are left out, since the user can apply again.
+			applied := make(map[int64]string)
+			open := 0
+			for _, resp := range responses {
+				if repo.IsFinalResponse(resp.Status) {
+					continue
+				}
+				open++
+				if resp.RoleID != nil {
+					applied[*resp.RoleID] = resp.Status
+				}
+			}
+			data["AppliedRoles"] = applied
 
-		if user.ID != project.AuthorID {
-			if resp, err := h.repo.GetUserResponseForProject(r.Context(), project.ID, user.ID); err == nil {
-				data["HasResponded"] = true
-				data["UserResponseStatus"] = resp.Status
+			canApply := len(project.Roles) == 0 && open == 0
+			for _, role := range project.Roles {
+				if _, ok := applied[role.ID]; !ok && role.Filled < role.Count {
+					canApply = true
+				}
+			}
+			if canApply && !project.IsClosed {
+				data["CanApply"] = true
+				if questions, err := h.repo.ListProjectQuestions(r.Context(), project.ID); err == nil {
+					data["Questions"] = questions
+				}
 			}
 		}
 
-		if user.ID == project.AuthorID {
+		var members []models.ProjectMember
+		if canManage {
 			responses, _ := h.repo.ListProjectResponses(r.Context(), project.ID)
 			data["Responses"] = responses
+
+			fullRoles := make(map[int64]bool)
+			for _, role := range project.Roles {
+				if role.Filled >= role.Count {
+					fullRoles[role.ID] = true
+				}
+			}
+			data["FullRoles"] = fullRoles
+
+			members, err = h.repo.ListProjectMembers(r.Context(), project.ID)
+			if err != nil {
+				log.Printf("list project members: %v", err)
+			}
+			data["Members"] = members
+		}
+
+		if h.can(r.Context(), user, project, permManageMembers) {
+			data["IsOwner"] = true
+
+			// Team members who don't help run the project yet can be made
+			// maintainers.
+			running := make(map[int64]bool)
+			for _, m := range members {
+				running[m.UserID] = true
+			}
+			var candidates []*models.User
+			for _, m := range team {
+				if !running[m.UserID] {
+					running[m.UserID] = true
+					candidates = append(candidates, m.User)
+				}
+			}
+			data["MaintainerCandidates"] = candidates
 		}
 	}
 
 	h.render(w, r, "project_view.html", data)
 }
 
+// teamGroup is the project's members holding one role. Role is nil for
+// members who joined without a specific role.
+type teamGroup struct {
+	Role    *models.Role
+	Members []models.Response
+}
+
+// groupTeam groups team members by role, keeping the order they come in.
+func groupTeam(team []models.Response) []teamGroup {
+	var groups []teamGroup
+	for _, m := range team {
+		n := len(groups)
+		if n > 0 && sameRole(groups[n-1].Role, m.Role) {
+			groups[n-1].Members = append(groups[n-1].Members, m)
+			continue
+		}
+		groups = append(groups, teamGroup{Role: m.Role, Members: []models.Response{m}})
+	}
+	return groups
+}
+
+func sameRole(a, b *models.Role) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID
+}
+
+// authorProjectResponses loads the project's responses for its author,
+// narrowed down by the role and status filters from the query string.
+func (h *Handler) authorProjectResponses(w http.ResponseWriter, r *http.Request) (*models.Project, []models.Response) {
+	project := h.projectBySlug(w, r)
+	if project == nil {
+		return nil, nil
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if !h.can(r.Context(), user, project, permManageResponses) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, nil
+	}
+
+	responses, err := h.repo.ListProjectResponses(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("list project responses: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return nil, nil
+	}
+
+	roleID, _ := strconv.ParseInt(r.URL.Query().Get("role"), 10, 64)
+	status := r.URL.Query().Get("status")
+	filtered := responses[:0]
+	for _, resp := range responses {
+		if roleID > 0 && (resp.RoleID == nil || *resp.RoleID != roleID) {
+			continue
+		}
+		if status != "" && resp.Status != status {
+			continue
+		}
+		filtered = append(filtered, resp)
+	}
+	return project, filtered
+}
+
+func (h *Handler) handleProjectResponses(w http.ResponseWriter, r *http.Request) {
+	project, responses := h.authorProjectResponses(w, r)
+	if project == nil {
+		return
+	}
+
+	fullRoles := make(map[int64]bool)
+	for _, role := range project.Roles {
+		if role.Filled >= role.Count {
+			fullRoles[role.ID] = true
+		}
+	}
+
+	q := r.URL.Query()
+	updated, _ := strconv.Atoi(q.Get("updated"))
+	skipped, _ := strconv.Atoi(q.Get("skipped"))
+	exportURL := fmt.Sprintf("/project/%s/responses.csv", project.Slug)
+	filter := url.Values{}
+	for _, key := range []string{"role", "status"} {
+		if v := q.Get(key); v != "" {
+			filter.Set(key, v)
+		}
+	}
+	if len(filter) > 0 {
+		exportURL += "?" + filter.Encode()
+	}
+
+	h.render(w, r, "project_responses.html", map[string]any{
+		"Project":      project,
+		"Responses":    responses,
+		"FullRoles":    fullRoles,
+		"FilterRole":   q.Get("role"),
+		"FilterStatus": q.Get("status"),
+		"ExportURL":    exportURL,
+		"Statuses": []string{
+			models.ResponsePending, models.ResponseShortlisted, models.ResponseInterview,
+			models.ResponseAccepted, models.ResponseRejected, models.ResponseWithdrawn, models.ResponseExpired,
+			models.ResponseLeft, models.ResponseRemoved,
+		},
+		"Updated":  updated,
+		"Skipped":  skipped,
+		"BulkDone": q.Has("updated"),
+	})
+}
+
+// handleExportResponses sends the filtered applicants as a CSV file the
+// author can open in a spreadsheet.
+func (h *Handler) handleExportResponses(w http.ResponseWriter, r *http.Request) {
+	project, responses := h.authorProjectResponses(w, r)
+	if project == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="responses-%s.csv"`, project.Slug))
+	// Excel only detects UTF-8 with a byte order mark.
+	_, _ = w.Write([]byte("\xEF\xBB\xBF"))
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"Имя", "Telegram", "Роль", "Навыки", "Опыт", "Сообщение", "Статус", "Дата"})
+	for _, resp := range responses {
+		var role string
+		if resp.Role != nil {
+			role = resp.Role.Name
+		}
+		_ = cw.Write([]string{
+			csvSafe(resp.User.Name),
+			tgLink(resp.User),
+			role,
+			csvSafe(strings.Join(resp.User.Skills, ", ")),
+			csvSafe(resp.User.Experience),
+			csvSafe(resp.Message),
+			models.ResponseStatusText(resp.Status),
+			resp.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("export responses: %v", err)
+	}
+}
+
+// csvSafe keeps user-supplied text from being read as a spreadsheet formula.
+// Some spreadsheets also treat a leading tab or carriage return as the start
+// of one.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
 func (h *Handler) handleProjectNew(w http.ResponseWriter, r *http.Request) {
 	roles, _ := h.repo.GetAllRoles(r.Context())
 	h.render(w, r, "project_form.html", map[string]any{
-		"Roles":  roles,
-		"IsEdit": false,
+		"Roles":                roles,
+		"IsEdit":               false,
+		"DefaultRejectReasons": defaultRejectReasons,
 	})
 }
 
@@ -112,7 +722,7 @@ func (h *Handler) handleProjectEdit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middleware.UserFromContext(r.Context())
-	if user.ID != project.AuthorID {
+	if !h.can(r.Context(), user, project, permEditProject) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -124,12 +734,19 @@ func (h *Handler) handleProjectEdit(w http.ResponseWriter, r *http.Request) {
 		roleCountMap[role.ID] = role.Count
 	}
 
+	questions, err := h.repo.ListProjectQuestions(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("list project questions: %v", err)
+	}
+
 	h.render(w, r, "project_form.html", map[string]any{
-		"Roles":         roles,
-		"IsEdit":        true,
-		"Project":       project,
-		"ProjectRoles":  roleIDs,
-		"RoleCountMap":  roleCountMap,
+		"Questions":            questions,
+		"Roles":                roles,
+		"IsEdit":               true,
+		"Project":              project,
+		"ProjectRoles":         roleIDs,
+		"RoleCountMap":         roleCountMap,
+		"DefaultRejectReasons": defaultRejectReasons,
 	})
 }
 
@@ -146,9 +763,33 @@ func (h *Handler) handleUserProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.render(w, r, "user.html", map[string]any{
+	data := map[string]any{
 		"Profile": profile,
-	})
+	}
+
+	// Authors can invite the person to one of their open projects.
+	viewer := middleware.UserFromContext(r.Context())
+	if viewer != nil && viewer.ID != profile.ID && profile.Onboarded && !profile.IsBanned {
+		projects, err := h.repo.ListUserProjects(r.Context(), viewer.ID)
+		if err != nil {
+			log.Printf("list user projects: %v", err)
+		}
+		var open []models.Project
+		for _, p := range projects {
+			if p.Status == "active" && !p.IsClosed && len(p.Roles) > 0 {
+				open = append(open, p)
+			}
+		}
+		data["InviteProjects"] = open
+
+		sent, err := h.repo.ListSentInvitations(r.Context(), viewer.ID, profile.ID)
+		if err != nil {
+			log.Printf("list sent invitations: %v", err)
+		}
+		data["SentInvitations"] = sent
+	}
+
+	h.render(w, r, "user.html", data)
 }
 
 func (h *Handler) handleOnboarding(w http.ResponseWriter, r *http.Request) {
@@ -160,7 +801,8 @@ func (h *Handler) handleOnboarding(w http.ResponseWriter, r *http.Request) {
 
 	roles, _ := h.repo.GetAllRoles(r.Context())
 	h.render(w, r, "onboarding.html", map[string]any{
-		"Roles": roles,
+		"Roles":      roles,
+		"NotifyLink": h.botStartLink(telegram.StartNotify, user.ID),
 	})
 }
 
@@ -177,9 +819,34 @@ func (h *Handler) handleSettings(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	roleIDs := extractRoleIDs(user.Roles)
 
+	searches, err := h.repo.ListSavedSearches(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("list saved searches: %v", err)
+	}
+
+	type savedSearchItem struct {
+		models.SavedSearch
+		Roles []models.Role
+	}
+
+	items := make([]savedSearchItem, 0, len(searches))
+	for _, s := range searches {
+		item := savedSearchItem{SavedSearch: s}
+		for _, role := range roles {
+			if hasString(s.RoleSlugs, role.Slug) {
+				item.Roles = append(item.Roles, role)
+			}
+		}
+		items = append(items, item)
+	}
+
 	h.render(w, r, "settings.html", map[string]any{
-		"Roles":     roles,
-		"UserRoles": roleIDs,
+		"Roles":            roles,
+		"UserRoles":        roleIDs,
+		"SavedSearches":    items,
+		"CanSaveSearch":    len(searches) < repo.MaxSavedSearches,
+		"MaxSavedSearches": repo.MaxSavedSearches,
+		"NotifyLink":       h.botStartLink(telegram.StartNotify, user.ID),
 	})
 }
 
@@ -207,6 +874,9 @@ func (h *Handler) handleMyProjects(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// incomingResponsesLimit caps the author's recent responses on /my/responses.
+const incomingResponsesLimit = 20
+
 func (h *Handler) handleMyResponses(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	responses, err := h.repo.ListUserResponses(r.Context(), user.ID)
@@ -215,8 +885,34 @@ func (h *Handler) handleMyResponses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	invitations, err := h.repo.ListUserInvitations(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("list invitations: %v", err)
+	}
+
+	incoming, err := h.repo.ListIncomingResponses(r.Context(), user.ID, incomingResponsesLimit)
+	if err != nil {
+		log.Printf("list incoming responses: %v", err)
+	}
+
 	h.render(w, r, "my_responses.html", map[string]any{
-		"Responses": responses,
+		"Responses":   responses,
+		"Invitations": invitations,
+		"Incoming":    incoming,
+	})
+}
+
+func (h *Handler) handleMySaved(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	projects, err := h.repo.ListSavedProjects(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("list saved projects: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.render(w, r, "my_saved.html", map[string]any{
+		"Projects": projects,
 	})
 }
 
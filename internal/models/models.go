@@ -23,9 +23,15 @@ type User struct {
 	Onboarded  bool
 	IsAdmin    bool
 	IsBanned   bool
+	IsPublic   bool
+	FeedSort   string
 	Roles      []Role
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	// NotifyRejected is whether the bot tells the user about rejected
+	// responses; the in-app notification is sent regardless.
+	NotifyRejected bool
 }
 
 type Project struct {
@@ -36,11 +42,17 @@ type Project struct {
 	Description string
 	Status      string
 	IsClosed    bool
+	AutoClose   bool
 	Stack       []string
 	Roles       []Role
 	Author      *User
+	Snippet     string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+
+	// RejectReasons are the author's canned reasons offered when rejecting
+	// a response.
+	RejectReasons []string
 }
 
 type AdminStats struct {
@@ -52,16 +64,158 @@ type AdminStats struct {
 	ResponseCount   int
 }
 
+type FacetCount struct {
+	Value string
+	Count int
+}
+
+// Recommendation is a project suggested to a user with the points it got
+// for each signal, so the feed can explain the suggestion.
+type Recommendation struct {
+	Project      Project
+	MatchedRoles []Role
+	MatchedStack []string
+	RoleScore    int
+	StackScore   int
+	SeatsScore   int
+	FreshScore   int
+}
+
+func (r Recommendation) Score() int {
+	return r.RoleScore + r.StackScore + r.SeatsScore + r.FreshScore
+}
+
+type SavedSearch struct {
+	ID        int64
+	UserID    int64
+	RoleSlugs []string
+	Stack     []string
+	Query     string
+	CreatedAt time.Time
+}
+
 type Response struct {
 	ID        int64
 	ProjectID int64
 	UserID    int64
 	RoleID    *int64
 	Status    string
+	Message   string
+	Links     []string
+	Answers   []Answer
+	Events    []ResponseEvent
 	User      *User
 	Project   *Project
 	Role      *Role
 	CreatedAt time.Time
+
+	// RejectReason is the author's optional explanation for a rejection or
+	// for removing the member from the team.
+	RejectReason string
+}
+
+// Response statuses. A response moves through the pipeline
+// pending → shortlisted → interview → accepted/rejected; the applicant can
+// withdraw it at any point before a decision. An accepted member can later
+// leave the team or be removed from it by the author.
+const (
+	ResponsePending     = "pending"
+	ResponseShortlisted = "shortlisted"
+	ResponseInterview   = "interview"
+	ResponseAccepted    = "accepted"
+	ResponseRejected    = "rejected"
+	ResponseWithdrawn   = "withdrawn"
+	ResponseLeft        = "left"
+	ResponseRemoved     = "removed"
+
+	// ResponseExpired is set by the expiry job, never by a person: the
+	// response sat in pending too long or the project stopped recruiting.
+	ResponseExpired = "expired"
+)
+
+var responseStatusTexts = map[string]string{
+	ResponsePending:     "На рассмотрении",
+	ResponseShortlisted: "В шорт-листе",
+	ResponseInterview:   "Собеседование",
+	ResponseAccepted:    "Принят",
+	ResponseRejected:    "Отклонён",
+	ResponseWithdrawn:   "Отозван",
+	ResponseExpired:     "Истёк",
+	ResponseLeft:        "Покинул команду",
+	ResponseRemoved:     "Исключён",
+}
+
+// ResponseStatusText returns the human-readable name of a response status.
+func ResponseStatusText(s string) string {
+	if v, ok := responseStatusTexts[s]; ok {
+		return v
+	}
+	return s
+}
+
+// ProjectMember is someone who runs a project: its owner or a maintainer
+// the owner picked from the team.
+type ProjectMember struct {
+	ProjectID int64
+	UserID    int64
+	Role      string
+	User      *User
+	CreatedAt time.Time
+}
+
+// Project member roles.
+const (
+	ProjectOwner      = "owner"
+	ProjectMaintainer = "maintainer"
+)
+
+// ResponseEvent is one step in a response's status history. FromStatus is
+// empty for the event that created the response.
+type ResponseEvent struct {
+	ID         int64
+	ResponseID int64
+	ActorID    *int64
+	ActorName  string
+	FromStatus string
+	ToStatus   string
+	CreatedAt  time.Time
+}
+
+// Question kinds an author can ask applicants.
+const (
+	QuestionText   = "text"
+	QuestionChoice = "choice"
+	QuestionYesNo  = "yesno"
+)
+
+type Question struct {
+	ID        int64
+	ProjectID int64
+	Kind      string
+	Prompt    string
+	Options   []string
+	Required  bool
+}
+
+type Answer struct {
+	QuestionID *int64
+	Prompt     string
+	Answer     string
+}
+
+type Invitation struct {
+	ID        int64
+	ProjectID int64
+	RoleID    int64
+	UserID    int64
+	InviterID int64
+	Status    string
+	Project   *Project
+	Role      *Role
+	User      *User
+	Inviter   *User
+	CreatedAt time.Time
+	ExpiresAt time.Time
 }
 
 type Notification struct {
@@ -72,3 +226,12 @@ type Notification struct {
 	Read      bool
 	CreatedAt time.Time
 }
+
+// BotLogin is a site login waiting to be confirmed in the bot, with where it
+// was started so the person confirming can tell whether it is theirs.
+type BotLogin struct {
+	Token     string
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+}
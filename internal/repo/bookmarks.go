@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"svyaz/internal/models"
+)
+
+func (r *Repo) SaveProject(ctx context.Context, userID, projectID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO bookmarks (user_id, project_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		userID, projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("save project: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) UnsaveProject(ctx context.Context, userID, projectID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM bookmarks WHERE user_id = ? AND project_id = ?`, userID, projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("unsave project: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) IsProjectSaved(ctx context.Context, userID, projectID int64) (bool, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT 1 FROM bookmarks WHERE user_id = ? AND project_id = ?`, userID, projectID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListSavedProjects returns the user's bookmarked projects that are still
+// visible in the feed, most recently saved first.
+func (r *Repo) ListSavedProjects(ctx context.Context, userID int64) ([]models.Project, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT p.id, p.slug, p.author_id, p.title, p.description, p.stack, p.status, p.is_closed, p.created_at, p.updated_at
+		 FROM bookmarks b JOIN projects p ON p.id = b.project_id
+		 WHERE b.user_id = ? AND p.status = 'active'
+		 ORDER BY b.created_at DESC, p.id DESC`, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list saved projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var p models.Project
+		var stackJSON string
+		if err := rows.Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(stackJSON), &p.Stack)
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		roles, err := r.getProjectRoles(ctx, projects[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		projects[i].Roles = roles
+
+		author, err := r.GetUser(ctx, projects[i].AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		projects[i].Author = author
+	}
+	return projects, nil
+}
+
+// ListProjectSavers returns ids of users who bookmarked the project.
+func (r *Repo) ListProjectSavers(ctx context.Context, projectID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id FROM bookmarks WHERE project_id = ?`, projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list project savers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
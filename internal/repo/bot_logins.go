@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"svyaz/internal/models"
+	"time"
+)
+
+// BotLoginTTL is how long a login started on the site waits to be confirmed
+// in the bot and picked up by the browser.
+const BotLoginTTL = 10 * time.Minute
+
+// ErrBotLoginExpired is returned for a login that doesn't exist, timed out or
+// was already used.
+var ErrBotLoginExpired = errors.New("bot login expired")
+
+// botLoginAge is the datetime('now', ?) modifier for BotLoginTTL.
+var botLoginAge = fmt.Sprintf("-%d seconds", int(BotLoginTTL.Seconds()))
+
+// CreateBotLogin starts a login that the user confirms in the bot, recording
+// the address and user agent of the browser that started it. Logins that
+// expired unused are cleaned up along the way.
+func (r *Repo) CreateBotLogin(ctx context.Context, token, ip, userAgent string) error {
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM bot_logins WHERE created_at <= datetime('now', ?)`, botLoginAge,
+	); err != nil {
+		return fmt.Errorf("clean bot logins: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO bot_logins (token, ip, user_agent) VALUES (?, ?, ?)`, token, ip, userAgent); err != nil {
+		return fmt.Errorf("create bot login: %w", err)
+	}
+	return nil
+}
+
+// GetBotLogin returns a login still waiting to be confirmed, or
+// ErrBotLoginExpired.
+func (r *Repo) GetBotLogin(ctx context.Context, token string) (*models.BotLogin, error) {
+	l := &models.BotLogin{}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT token, ip, user_agent, created_at FROM bot_logins
+		 WHERE token = ? AND user_id IS NULL AND created_at > datetime('now', ?)`,
+		token, botLoginAge,
+	).Scan(&l.Token, &l.IP, &l.UserAgent, &l.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrBotLoginExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get bot login: %w", err)
+	}
+	return l, nil
+}
+
+// ConfirmBotLogin marks the login as confirmed by the user. It reports false
+// if the login doesn't exist, expired or was already confirmed.
+func (r *Repo) ConfirmBotLogin(ctx context.Context, token string, userID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE bot_logins SET user_id = ?
+		 WHERE token = ? AND user_id IS NULL AND created_at > datetime('now', ?)`,
+		userID, token, botLoginAge,
+	)
+	if err != nil {
+		return false, fmt.Errorf("confirm bot login: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TakeBotLogin returns the user who confirmed the login and deletes it, so it
+// can be used once. It returns zero while the login is not confirmed yet, and
+// ErrBotLoginExpired once it can't be anymore.
+func (r *Repo) TakeBotLogin(ctx context.Context, token string) (int64, error) {
+	var userID int64
+	err := r.db.QueryRowContext(ctx,
+		`DELETE FROM bot_logins
+		 WHERE token = ? AND user_id IS NOT NULL AND created_at > datetime('now', ?)
+		 RETURNING user_id`,
+		token, botLoginAge,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetBotLogin(ctx, token); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("take bot login: %w", err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,265 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"svyaz/internal/models"
+	"time"
+)
+
+const (
+	// InvitationTTL is how long an invitation stays open before it expires.
+	InvitationTTL = 14 * 24 * time.Hour
+	// InvitationDailyLimit caps invitations one author can send per day.
+	InvitationDailyLimit = 20
+)
+
+var (
+	ErrInvitationPending  = errors.New("invitation already pending")
+	ErrInvitationDeclined = errors.New("invitation was declined")
+	ErrInvitationLimit    = errors.New("daily invitation limit reached")
+	ErrInvitationClosed   = errors.New("invitation is no longer pending")
+	ErrProjectClosed      = errors.New("project is not recruiting")
+)
+
+// invitationStatusSQL is the status of invitation i. Pending invitations
+// past their expiry read as expired, so reads never have to write.
+const invitationStatusSQL = `CASE WHEN i.status = 'pending' AND i.expires_at <= CURRENT_TIMESTAMP
+	THEN 'expired' ELSE i.status END`
+
+// CreateInvitation invites a user to a project role. An author can't invite
+// the same person to a project twice while one is pending or after it was
+// declined, and can only send InvitationDailyLimit invitations a day.
+func (r *Repo) CreateInvitation(ctx context.Context, projectID, roleID, userID, inviterID int64) (int64, error) {
+	var status string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT status FROM invitations
+		 WHERE project_id = ? AND user_id = ?
+		   AND (status = 'pending' AND expires_at > CURRENT_TIMESTAMP OR status = 'declined')
+		 ORDER BY status = 'pending' DESC LIMIT 1`, projectID, userID,
+	).Scan(&status)
+	switch {
+	case err == nil && status == "pending":
+		return 0, ErrInvitationPending
+	case err == nil:
+		return 0, ErrInvitationDeclined
+	case err != sql.ErrNoRows:
+		return 0, fmt.Errorf("check invitation: %w", err)
+	}
+
+	var sent int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM invitations WHERE inviter_id = ? AND created_at > datetime('now', '-1 day')`, inviterID,
+	).Scan(&sent); err != nil {
+		return 0, fmt.Errorf("count invitations: %w", err)
+	}
+	if sent >= InvitationDailyLimit {
+		return 0, ErrInvitationLimit
+	}
+
+	// An expired invitation still holds the one pending slot per project in
+	// the unique index until it is marked as such.
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE invitations SET status = 'expired'
+		 WHERE project_id = ? AND user_id = ? AND status = 'pending' AND expires_at <= CURRENT_TIMESTAMP`,
+		projectID, userID,
+	); err != nil {
+		return 0, fmt.Errorf("expire invitation: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO invitations (project_id, role_id, user_id, inviter_id, expires_at)
+		 VALUES (?, ?, ?, ?, datetime('now', ?))`,
+		projectID, roleID, userID, inviterID, fmt.Sprintf("+%d seconds", int(InvitationTTL.Seconds())),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create invitation: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *Repo) GetInvitation(ctx context.Context, id int64) (*models.Invitation, error) {
+	inv := &models.Invitation{}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT i.id, i.project_id, i.role_id, i.user_id, i.inviter_id, `+invitationStatusSQL+`, i.created_at, i.expires_at
+		 FROM invitations i WHERE i.id = ?`, id,
+	).Scan(&inv.ID, &inv.ProjectID, &inv.RoleID, &inv.UserID, &inv.InviterID, &inv.Status, &inv.CreatedAt, &inv.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// AcceptInvitation accepts a pending invitation and records the invitee as
+// an accepted response for the invited role. A response they already made
+// for the role moves on to accepted; one that reached a final status is
+// reopened first, as if they had applied again. It fails with
+// ErrProjectClosed if the project stopped recruiting and with
+// ErrInvitationClosed if the role was dropped from it.
+func (r *Repo) AcceptInvitation(ctx context.Context, id, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin accept invitation: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE invitations SET status = 'accepted', responded_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND user_id = ? AND status = 'pending' AND expires_at > CURRENT_TIMESTAMP`, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("accept invitation: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvitationClosed
+	}
+
+	var projectID, roleID int64
+	err = tx.QueryRowContext(ctx, `SELECT project_id, role_id FROM invitations WHERE id = ?`, id).Scan(&projectID, &roleID)
+	if err != nil {
+		return fmt.Errorf("get invitation: %w", err)
+	}
+
+	var open bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT status = 'active' AND is_closed = 0 FROM projects WHERE id = ?`, projectID,
+	).Scan(&open)
+	if err != nil {
+		return fmt.Errorf("get invitation project: %w", err)
+	}
+	if !open {
+		return ErrProjectClosed
+	}
+
+	var exists int
+	err = tx.QueryRowContext(ctx,
+		`SELECT 1 FROM project_roles WHERE project_id = ? AND role_id = ?`, projectID, roleID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrInvitationClosed
+	}
+	if err != nil {
+		return fmt.Errorf("get invitation role: %w", err)
+	}
+	if err := checkRoleCapacity(ctx, tx, projectID, roleID, userID); err != nil {
+		return err
+	}
+
+	var responseID int64
+	var from string
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, status FROM responses WHERE project_id = ? AND user_id = ? AND role_id = ?`, projectID, userID, roleID,
+	).Scan(&responseID, &from)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO responses (project_id, user_id, role_id, status) VALUES (?, ?, ?, 'accepted') RETURNING id`,
+			projectID, userID, roleID,
+		).Scan(&responseID)
+		if err != nil {
+			return fmt.Errorf("accept invitation response: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("accept invitation response: %w", err)
+	case from == models.ResponseAccepted:
+		// Already on the team for this role; only the invitation changes.
+		return tx.Commit()
+	default:
+		if IsFinalResponse(from) {
+			if err := reopenResponse(ctx, tx, responseID, userID, from); err != nil {
+				return err
+			}
+			from = models.ResponsePending
+		}
+		if !CanTransition(from, models.ResponseAccepted) {
+			return ErrInvalidTransition
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE responses SET status = 'accepted', reject_reason = '' WHERE id = ?`, responseID,
+		); err != nil {
+			return fmt.Errorf("accept invitation response: %w", err)
+		}
+	}
+
+	if err := recordResponseEvent(ctx, tx, responseID, userID, from, models.ResponseAccepted); err != nil {
+		return err
+	}
+
+	if err := closeFilledProject(ctx, tx, projectID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repo) DeclineInvitation(ctx context.Context, id, userID int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE invitations SET status = 'declined', responded_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND user_id = ? AND status = 'pending' AND expires_at > CURRENT_TIMESTAMP`, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("decline invitation: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+// ListUserInvitations returns pending invitations sent to the user, newest first.
+func (r *Repo) ListUserInvitations(ctx context.Context, userID int64) ([]models.Invitation, error) {
+	return r.listInvitations(ctx, `i.user_id = ? AND i.status = 'pending' AND i.expires_at > CURRENT_TIMESTAMP`, userID)
+}
+
+// ListSentInvitations returns invitations the inviter sent to the user,
+// newest first, so a profile shows what is already pending.
+func (r *Repo) ListSentInvitations(ctx context.Context, inviterID, userID int64) ([]models.Invitation, error) {
+	return r.listInvitations(ctx, `i.inviter_id = ? AND i.user_id = ?`, inviterID, userID)
+}
+
+func (r *Repo) listInvitations(ctx context.Context, where string, args ...interface{}) ([]models.Invitation, error) {
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT i.id, i.project_id, i.role_id, i.user_id, i.inviter_id, `+invitationStatusSQL+`, i.created_at, i.expires_at,
+		        rl.slug, rl.name
+		 FROM invitations i JOIN roles rl ON rl.id = i.role_id
+		 WHERE `+where+` ORDER BY i.created_at DESC, i.id DESC`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		var inv models.Invitation
+		role := &models.Role{}
+		if err := rows.Scan(&inv.ID, &inv.ProjectID, &inv.RoleID, &inv.UserID, &inv.InviterID, &inv.Status, &inv.CreatedAt, &inv.ExpiresAt,
+			&role.Slug, &role.Name); err != nil {
+			return nil, err
+		}
+		role.ID = inv.RoleID
+		inv.Role = role
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range invitations {
+		project, err := r.GetProject(ctx, invitations[i].ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		invitations[i].Project = project
+
+		inviter, err := r.GetUser(ctx, invitations[i].InviterID)
+		if err != nil {
+			return nil, err
+		}
+		invitations[i].Inviter = inviter
+	}
+	return invitations, nil
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"svyaz/internal/models"
+)
+
+// GetProjectMemberRole returns the user's role in running the project, or an
+// empty string if they don't help run it.
+func (r *Repo) GetProjectMemberRole(ctx context.Context, projectID, userID int64) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT role FROM project_members WHERE project_id = ? AND user_id = ?`, projectID, userID,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get project member role: %w", err)
+	}
+	return role, nil
+}
+
+// ListProjectMembers returns the people running the project, owner first.
+func (r *Repo) ListProjectMembers(ctx context.Context, projectID int64) ([]models.ProjectMember, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT project_id, user_id, role, created_at FROM project_members
+		 WHERE project_id = ? ORDER BY role = 'owner' DESC, created_at`, projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list project members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.ProjectMember
+	for rows.Next() {
+		var m models.ProjectMember
+		if err := rows.Scan(&m.ProjectID, &m.UserID, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range members {
+		user, err := r.GetUser(ctx, members[i].UserID)
+		if err != nil {
+			return nil, err
+		}
+		members[i].User = user
+	}
+	return members, nil
+}
+
+// AddProjectMaintainer lets the user help run the project. It does nothing
+// if they already do.
+func (r *Repo) AddProjectMaintainer(ctx context.Context, projectID, userID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO project_members (project_id, user_id, role) VALUES (?, ?, 'maintainer')
+		 ON CONFLICT (project_id, user_id) DO NOTHING`, projectID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("add project maintainer: %w", err)
+	}
+	return nil
+}
+
+// RemoveProjectMaintainer takes a maintainer's rights away. The owner can't
+// be removed this way.
+func (r *Repo) RemoveProjectMaintainer(ctx context.Context, projectID, userID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM project_members WHERE project_id = ? AND user_id = ? AND role = 'maintainer'`,
+		projectID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("remove project maintainer: %w", err)
+	}
+	return nil
+}
+
+// TransferProjectOwnership makes userID the project's owner and author. The
+// previous owner stays on as a maintainer.
+func (r *Repo) TransferProjectOwnership(ctx context.Context, projectID, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transfer ownership: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE project_members SET role = 'maintainer' WHERE project_id = ? AND role = 'owner'`, projectID,
+	); err != nil {
+		return fmt.Errorf("demote owner: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO project_members (project_id, user_id, role) VALUES (?, ?, 'owner')
+		 ON CONFLICT (project_id, user_id) DO UPDATE SET role = 'owner'`, projectID, userID,
+	); err != nil {
+		return fmt.Errorf("set owner: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE projects SET author_id = ? WHERE id = ?`, userID, projectID,
+	); err != nil {
+		return fmt.Errorf("set project author: %w", err)
+	}
+
+	return tx.Commit()
+}
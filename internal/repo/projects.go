@@ -3,10 +3,13 @@ package repo
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"svyaz/internal/models"
+	"unicode"
 )
 
 const slugChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -22,11 +25,197 @@ func generateSlug() string {
 	return string(b)
 }
 
+// Markers wrapped around matched terms in Project.Snippet. They are control
+// characters so they can't collide with user text and survive HTML escaping.
+const (
+	SnippetOpen  = "\x02"
+	SnippetClose = "\x03"
+)
+
 type ProjectFilter struct {
-	RoleSlug string
-	Stack    string
-	Limit    int
-	Offset   int
+	RoleSlugs []string
+	// AllRoles requires every role in RoleSlugs instead of any of them.
+	AllRoles bool
+	Stack    []string
+	// AllStack requires every tag in Stack instead of any of them.
+	AllStack bool
+	// OpenOnly hides projects whose author closed recruitment.
+	OpenOnly bool
+	// HasSeats keeps projects with at least one role not yet filled.
+	HasSeats bool
+	Query    string
+	// Sort is one of the Sort* constants; empty means relevance when
+	// searching and newest otherwise.
+	Sort string
+	// After is the cursor returned with the previous page; empty for the first page.
+	After string
+	Limit int
+}
+
+// acceptedForRoleSQL counts accepted responses for project role pr.
+const acceptedForRoleSQL = `(SELECT COUNT(*) FROM responses resp
+	WHERE resp.project_id = pr.project_id
+	  AND resp.role_id = pr.role_id
+	  AND resp.status = 'accepted')`
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func stringArgs(ss []string) []interface{} {
+	args := make([]interface{}, len(ss))
+	for i, s := range ss {
+		args[i] = s
+	}
+	return args
+}
+
+// where builds the conditions on projects p shared by the feed and its facets.
+// Full-text search is left to the callers since they join the index differently.
+func (f ProjectFilter) where() ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if len(f.RoleSlugs) > 0 {
+		matched := `(SELECT COUNT(DISTINCT rl.slug) FROM project_roles pr JOIN roles rl ON rl.id = pr.role_id
+			WHERE pr.project_id = p.id AND rl.slug IN (` + placeholders(len(f.RoleSlugs)) + `))`
+		if f.AllRoles {
+			conditions = append(conditions, matched+` = ?`)
+			args = append(append(args, stringArgs(f.RoleSlugs)...), len(f.RoleSlugs))
+		} else {
+			conditions = append(conditions, matched+` > 0`)
+			args = append(args, stringArgs(f.RoleSlugs)...)
+		}
+	}
+
+	if len(f.Stack) > 0 {
+		matched := `(SELECT COUNT(DISTINCT t.value) FROM json_each(p.stack) t
+			WHERE t.value IN (` + placeholders(len(f.Stack)) + `))`
+		if f.AllStack {
+			conditions = append(conditions, matched+` = ?`)
+			args = append(append(args, stringArgs(f.Stack)...), len(f.Stack))
+		} else {
+			conditions = append(conditions, matched+` > 0`)
+			args = append(args, stringArgs(f.Stack)...)
+		}
+	}
+
+	if f.OpenOnly {
+		conditions = append(conditions, `p.is_closed = 0`)
+	}
+
+	if f.HasSeats {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM project_roles pr
+			WHERE pr.project_id = p.id AND pr.count > `+acceptedForRoleSQL+`)`)
+	}
+
+	conditions = append(conditions, `p.status = 'active'`)
+
+	return conditions, args
+}
+
+// whereMatching is where plus the full-text query, for callers that only
+// filter projects and don't rank them by relevance.
+func (f ProjectFilter) whereMatching() ([]string, []interface{}) {
+	conditions, args := f.where()
+	if match := ftsQuery(f.Query); match != "" {
+		conditions = append(conditions, `p.id IN (SELECT rowid FROM projects_fts WHERE projects_fts MATCH ?)`)
+		args = append(args, match)
+	}
+	return conditions, args
+}
+
+// Feed sort orders accepted by ProjectFilter.Sort.
+const (
+	SortNewest    = "new"
+	SortUpdated   = "updated"
+	SortSeats     = "seats"
+	SortQuiet     = "quiet"
+	SortPopular   = "popular"
+	SortRelevance = "relevance"
+)
+
+// feedOrder is the ordering of the project feed; ties are always broken by
+// newest id first. Pages are cut by keyset on (key, p.id), which only holds
+// while a project's key never changes: that is true of the creation time, so
+// approvals happening mid-browse don't shift later pages of the newest-first
+// feed. Edit times, response counts, free seats and search scores all move
+// while someone browses, so orders by them show a single page.
+type feedOrder struct {
+	key  string
+	desc bool
+	// single marks orders whose key changes while someone browses, so
+	// keyset pages would skip or repeat projects. They show one page.
+	single bool
+}
+
+const responseCountSQL = `(SELECT COUNT(*) FROM responses r WHERE r.project_id = p.id)`
+
+var feedOrders = map[string]feedOrder{
+	SortNewest:  {key: `CAST(p.created_at AS TEXT)`, desc: true},
+	SortUpdated: {key: `CAST(p.updated_at AS TEXT)`, desc: true, single: true},
+	SortSeats: {key: `(SELECT COALESCE(SUM(MAX(pr.count - ` + acceptedForRoleSQL + `, 0)), 0)
+		FROM project_roles pr WHERE pr.project_id = p.id)`, desc: true, single: true},
+	SortQuiet:   {key: responseCountSQL, single: true},
+	SortPopular: {key: responseCountSQL, desc: true, single: true},
+	// bm25 scores depend on the whole index and move with every project added.
+	SortRelevance: {key: `bm25(projects_fts, 10.0, 1.0, 5.0)`, single: true},
+}
+
+// ValidSort reports whether s is a known feed sort order.
+func ValidSort(s string) bool {
+	_, ok := feedOrders[s]
+	return ok
+}
+
+func encodeCursor(key any, id int64) string {
+	data, _ := json.Marshal([]any{key, id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ErrBadCursor is returned when a page cursor can't be decoded, which means
+// it came from the client rather than from a previous page.
+var ErrBadCursor = errors.New("invalid page cursor")
+
+func decodeCursor(s string) (key any, id int64, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, 0, ErrBadCursor
+	}
+	var parts []any
+	if err := json.Unmarshal(data, &parts); err != nil || len(parts) != 2 {
+		return nil, 0, ErrBadCursor
+	}
+	n, ok := parts[1].(float64)
+	if !ok {
+		return nil, 0, ErrBadCursor
+	}
+	// Sort keys are timestamps or numbers; anything else can't be bound.
+	switch parts[0].(type) {
+	case string, float64:
+	default:
+		return nil, 0, ErrBadCursor
+	}
+	return parts[0], int64(n), nil
+}
+
+// ftsQuery turns free-form user input into an FTS5 query: every word becomes
+// a quoted prefix term, so operators and punctuation can't break MATCH.
+func ftsQuery(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, `"`+w+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// HasSearchTerms reports whether q has anything full-text search can match,
+// as opposed to only punctuation and spaces.
+func HasSearchTerms(q string) bool {
+	return ftsQuery(q) != ""
 }
 
 func (r *Repo) CreateProject(ctx context.Context, authorID int64, title, description string, stack []string, roleCounts map[int64]int) (string, error) {
@@ -42,6 +231,11 @@ func (r *Repo) CreateProject(ctx context.Context, authorID int64, title, descrip
 	}
 	projectID, _ := res.LastInsertId()
 
+	_, err = r.db.ExecContext(ctx, `INSERT INTO project_members (project_id, user_id, role) VALUES (?, ?, 'owner')`, projectID, authorID)
+	if err != nil {
+		return "", fmt.Errorf("insert project owner: %w", err)
+	}
+
 	for rid, count := range roleCounts {
 		if count < 1 {
 			count = 1
@@ -57,14 +251,16 @@ func (r *Repo) CreateProject(ctx context.Context, authorID int64, title, descrip
 
 func (r *Repo) GetProject(ctx context.Context, id int64) (*models.Project, error) {
 	p := &models.Project{}
-	var stackJSON string
+	var stackJSON, reasonsJSON string
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, slug, author_id, title, description, stack, status, is_closed, created_at, updated_at FROM projects WHERE id = ?`, id,
-	).Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt)
+		`SELECT id, slug, author_id, title, description, stack, status, is_closed, auto_close, reject_reasons, created_at, updated_at
+		 FROM projects WHERE id = ?`, id,
+	).Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.AutoClose, &reasonsJSON, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("get project: %w", err)
 	}
 	_ = json.Unmarshal([]byte(stackJSON), &p.Stack)
+	_ = json.Unmarshal([]byte(reasonsJSON), &p.RejectReasons)
 
 	roles, err := r.getProjectRoles(ctx, p.ID)
 	if err != nil {
@@ -83,14 +279,16 @@ func (r *Repo) GetProject(ctx context.Context, id int64) (*models.Project, error
 
 func (r *Repo) GetProjectBySlug(ctx context.Context, slug string) (*models.Project, error) {
 	p := &models.Project{}
-	var stackJSON string
+	var stackJSON, reasonsJSON string
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, slug, author_id, title, description, stack, status, is_closed, created_at, updated_at FROM projects WHERE slug = ?`, slug,
-	).Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt)
+		`SELECT id, slug, author_id, title, description, stack, status, is_closed, auto_close, reject_reasons, created_at, updated_at
+		 FROM projects WHERE slug = ?`, slug,
+	).Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.AutoClose, &reasonsJSON, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("get project by slug: %w", err)
 	}
 	_ = json.Unmarshal([]byte(stackJSON), &p.Stack)
+	_ = json.Unmarshal([]byte(reasonsJSON), &p.RejectReasons)
 
 	roles, err := r.getProjectRoles(ctx, p.ID)
 	if err != nil {
@@ -141,72 +339,184 @@ func (r *Repo) DeleteProject(ctx context.Context, id int64) error {
 	return err
 }
 
-func (r *Repo) ListProjects(ctx context.Context, f ProjectFilter) ([]models.Project, error) {
-	query := `SELECT DISTINCT p.id, p.slug, p.author_id, p.title, p.description, p.stack, p.status, p.is_closed, p.created_at, p.updated_at FROM projects p`
+// ListProjects returns a page of active projects and the cursor for the next
+// page, which is empty when there are no more results or the order shows a
+// single page.
+func (r *Repo) ListProjects(ctx context.Context, f ProjectFilter) ([]models.Project, string, error) {
 	var args []interface{}
 	var conditions []string
 
-	if f.RoleSlug != "" {
-		query += ` JOIN project_roles pr ON pr.project_id = p.id JOIN roles rl ON rl.id = pr.role_id`
-		conditions = append(conditions, `rl.slug = ?`)
-		args = append(args, f.RoleSlug)
+	snippet := `''`
+	from := `projects p`
+
+	match := ftsQuery(f.Query)
+	sort := f.Sort
+	if (sort == SortRelevance && match == "") || !ValidSort(sort) {
+		sort = SortNewest
+		if match != "" {
+			sort = SortRelevance
+		}
+	}
+	// A cursor for a single-page order can only come from a stale or
+	// hand-made link.
+	if f.After != "" && feedOrders[sort].single {
+		sort = SortNewest
 	}
+	order := feedOrders[sort]
 
-	if f.Stack != "" {
-		conditions = append(conditions, `p.stack LIKE ?`)
-		args = append(args, `%"`+f.Stack+`"%`)
+	if match != "" {
+		snippet = `snippet(projects_fts, 1, char(2), char(3), '…', 24)`
+		from += ` JOIN projects_fts ON projects_fts.rowid = p.id`
+		conditions = append(conditions, `projects_fts MATCH ?`)
+		args = append(args, match)
 	}
 
-	conditions = append(conditions, `p.status = 'active'`)
+	where, whereArgs := f.where()
+	conditions = append(conditions, where...)
+	args = append(args, whereArgs...)
+
+	query := `SELECT id, slug, author_id, title, description, stack, status, is_closed, created_at, updated_at, snippet, sort_key
+		FROM (SELECT p.id, p.slug, p.author_id, p.title, p.description, p.stack, p.status, p.is_closed, p.created_at, p.updated_at,
+		             ` + snippet + ` AS snippet, ` + order.key + ` AS sort_key
+		      FROM ` + from + `
+		      WHERE ` + strings.Join(conditions, ` AND `) + `)`
 
-	if len(conditions) > 0 {
-		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	cmp, dir := ">", "ASC"
+	if order.desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if f.After != "" {
+		key, id, err := decodeCursor(f.After)
+		if err != nil {
+			return nil, "", err
+		}
+		query += ` WHERE (sort_key ` + cmp + ` ? OR (sort_key = ? AND id < ?))`
+		args = append(args, key, key, id)
 	}
 
-	query += ` ORDER BY p.created_at DESC`
+	query += ` ORDER BY sort_key ` + dir + `, id DESC LIMIT ?`
 
 	if f.Limit <= 0 {
 		f.Limit = 20
 	}
-	query += fmt.Sprintf(` LIMIT %d OFFSET %d`, f.Limit, f.Offset)
+	// Fetch one extra row to learn whether there is a next page.
+	args = append(args, f.Limit+1)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list projects: %w", err)
+		return nil, "", fmt.Errorf("list projects: %w", err)
 	}
 	defer rows.Close()
 
 	var projects []models.Project
+	var keys []any
 	for rows.Next() {
 		var p models.Project
 		var stackJSON string
-		if err := rows.Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt); err != nil {
-			return nil, err
+		var key any
+		if err := rows.Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt, &p.Snippet, &key); err != nil {
+			return nil, "", err
 		}
 		_ = json.Unmarshal([]byte(stackJSON), &p.Stack)
+		projects = append(projects, p)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list projects: %w", err)
+	}
+
+	var next string
+	if len(projects) > f.Limit {
+		projects = projects[:f.Limit]
+		if !order.single {
+			next = encodeCursor(keys[f.Limit-1], projects[f.Limit-1].ID)
+		}
+	}
 
-		roles, err := r.getProjectRoles(ctx, p.ID)
+	for i := range projects {
+		roles, err := r.getProjectRoles(ctx, projects[i].ID)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		p.Roles = roles
+		projects[i].Roles = roles
 
-		author, err := r.GetUser(ctx, p.AuthorID)
+		author, err := r.GetUser(ctx, projects[i].AuthorID)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		p.Author = author
+		projects[i].Author = author
+	}
 
-		projects = append(projects, p)
+	return projects, next, nil
+}
+
+// ProjectFacets counts matching projects per role slug and per stack tag.
+// Each facet ignores its own dimension of the filter, so the counts say how
+// many projects selecting that option would add or keep.
+func (r *Repo) ProjectFacets(ctx context.Context, f ProjectFilter) (map[string]int, []models.FacetCount, error) {
+	rf := f
+	rf.RoleSlugs = nil
+	conditions, args := rf.whereMatching()
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT rl.slug, COUNT(DISTINCT p.id) FROM projects p
+		 JOIN project_roles pr ON pr.project_id = p.id
+		 JOIN roles rl ON rl.id = pr.role_id
+		 WHERE `+strings.Join(conditions, ` AND `)+`
+		 GROUP BY rl.slug`, args...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("role facets: %w", err)
 	}
+	defer rows.Close()
 
-	return projects, nil
+	roles := make(map[string]int)
+	for rows.Next() {
+		var slug string
+		var count int
+		if err := rows.Scan(&slug, &count); err != nil {
+			return nil, nil, err
+		}
+		roles[slug] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("role facets: %w", err)
+	}
+
+	sf := f
+	sf.Stack = nil
+	conditions, args = sf.whereMatching()
+	tagRows, err := r.db.QueryContext(ctx,
+		`SELECT t.value, COUNT(DISTINCT p.id) AS n FROM projects p, json_each(p.stack) t
+		 WHERE `+strings.Join(conditions, ` AND `)+`
+		 GROUP BY t.value ORDER BY n DESC, t.value LIMIT 20`, args...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stack facets: %w", err)
+	}
+	defer tagRows.Close()
+
+	var tags []models.FacetCount
+	for tagRows.Next() {
+		var fc models.FacetCount
+		if err := tagRows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, nil, err
+		}
+		tags = append(tags, fc)
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("stack facets: %w", err)
+	}
+
+	return roles, tags, nil
 }
 
+// ListUserProjects returns the projects the user owns or maintains.
 func (r *Repo) ListUserProjects(ctx context.Context, userID int64) ([]models.Project, error) {
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, slug, author_id, title, description, stack, status, is_closed, created_at, updated_at
-		 FROM projects WHERE author_id = ? ORDER BY created_at DESC`, userID,
+		 FROM projects WHERE id IN (SELECT project_id FROM project_members WHERE user_id = ?)
+		 ORDER BY created_at DESC`, userID,
 	)
 	if err != nil {
 		return nil, err
@@ -254,11 +564,7 @@ func (r *Repo) getProjectRoles(ctx context.Context, projectID int64) ([]models.R
 
 func (r *Repo) GetProjectRolesWithFilled(ctx context.Context, projectID int64) ([]models.Role, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT r.id, r.slug, r.name, pr.count,
-		        COALESCE((SELECT COUNT(*) FROM responses resp
-		                  WHERE resp.project_id = pr.project_id
-		                    AND resp.role_id = r.id
-		                    AND resp.status = 'accepted'), 0) AS filled
+		`SELECT r.id, r.slug, r.name, pr.count, `+acceptedForRoleSQL+` AS filled
 		 FROM roles r
 		 JOIN project_roles pr ON pr.role_id = r.id
 		 WHERE pr.project_id = ?`, projectID,
@@ -285,6 +591,16 @@ func (r *Repo) CountProjectResponses(ctx context.Context, projectID int64) (int,
 	return count, err
 }
 
+// CountPendingResponses returns how many responses to the project still wait
+// for a first decision.
+func (r *Repo) CountPendingResponses(ctx context.Context, projectID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM responses WHERE project_id = ? AND status = 'pending'`, projectID,
+	).Scan(&count)
+	return count, err
+}
+
 func (r *Repo) SetProjectClosed(ctx context.Context, id int64, closed bool) error {
 	v := 0
 	if closed {
@@ -294,6 +610,25 @@ func (r *Repo) SetProjectClosed(ctx context.Context, id int64, closed bool) erro
 	return err
 }
 
+// SetProjectAutoClose sets whether the project closes on its own once every
+// role is filled.
+func (r *Repo) SetProjectAutoClose(ctx context.Context, id int64, autoClose bool) error {
+	v := 0
+	if autoClose {
+		v = 1
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE projects SET auto_close = ? WHERE id = ?`, v, id)
+	return err
+}
+
+// SetProjectRejectReasons replaces the canned rejection reasons the author
+// picks from when turning down a response.
+func (r *Repo) SetProjectRejectReasons(ctx context.Context, id int64, reasons []string) error {
+	reasonsJSON, _ := json.Marshal(reasons)
+	_, err := r.db.ExecContext(ctx, `UPDATE projects SET reject_reasons = ? WHERE id = ?`, string(reasonsJSON), id)
+	return err
+}
+
 func (r *Repo) BackfillSlugs(ctx context.Context) error {
 	rows, err := r.db.QueryContext(ctx, `SELECT id FROM projects WHERE slug = ''`)
 	if err != nil {
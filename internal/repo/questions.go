@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"svyaz/internal/models"
+)
+
+// MaxProjectQuestions caps how many screening questions a project can have.
+const MaxProjectQuestions = 5
+
+func (r *Repo) ListProjectQuestions(ctx context.Context, projectID int64) ([]models.Question, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, project_id, kind, prompt, options, required FROM project_questions
+		 WHERE project_id = ? ORDER BY position, id`, projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list project questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		var q models.Question
+		var options string
+		if err := rows.Scan(&q.ID, &q.ProjectID, &q.Kind, &q.Prompt, &options, &q.Required); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(options), &q.Options)
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+// SetProjectQuestions replaces the project's questions with the given list,
+// in order. Questions with an ID of an existing question are updated in
+// place so answers keep pointing at them; the rest are inserted, and
+// questions missing from the list are removed.
+func (r *Repo) SetProjectQuestions(ctx context.Context, projectID int64, questions []models.Question) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin set questions: %w", err)
+	}
+	defer tx.Rollback()
+
+	var keep []interface{}
+	for i, q := range questions {
+		options := q.Options
+		if options == nil {
+			options = []string{}
+		}
+		optionsJSON, _ := json.Marshal(options)
+
+		if q.ID != 0 {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE project_questions SET position = ?, kind = ?, prompt = ?, options = ?, required = ?
+				 WHERE id = ? AND project_id = ?`,
+				i, q.Kind, q.Prompt, string(optionsJSON), q.Required, q.ID, projectID,
+			)
+			if err != nil {
+				return fmt.Errorf("update question: %w", err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				keep = append(keep, q.ID)
+				continue
+			}
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO project_questions (project_id, position, kind, prompt, options, required) VALUES (?, ?, ?, ?, ?, ?)`,
+			projectID, i, q.Kind, q.Prompt, string(optionsJSON), q.Required,
+		)
+		if err != nil {
+			return fmt.Errorf("insert question: %w", err)
+		}
+		id, _ := res.LastInsertId()
+		keep = append(keep, id)
+	}
+
+	query := `DELETE FROM project_questions WHERE project_id = ?`
+	args := []interface{}{projectID}
+	if len(keep) > 0 {
+		query += ` AND id NOT IN (` + placeholders(len(keep)) + `)`
+		args = append(args, keep...)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("delete questions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repo) getResponseAnswers(ctx context.Context, responseID int64) ([]models.Answer, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT question_id, prompt, answer FROM response_answers WHERE response_id = ? ORDER BY id`, responseID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get response answers: %w", err)
+	}
+	defer rows.Close()
+
+	var answers []models.Answer
+	for rows.Next() {
+		var a models.Answer
+		if err := rows.Scan(&a.QuestionID, &a.Prompt, &a.Answer); err != nil {
+			return nil, err
+		}
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"svyaz/internal/models"
+)
+
+// Recommendation weights. A project needs a role or stack match to be
+// suggested at all; seats and freshness only reorder the matches.
+const (
+	recRolePoints   = 30 // per matching role, up to two
+	recStackPoints  = 10 // per matching tag, up to five
+	recSeatsPoints  = 15 // a matching role still has free seats
+	recFreshPoints  = 20 // just published, fading to zero
+	recFreshDays    = 30
+	recMaxRoles     = 2
+	recMaxStackTags = 5
+)
+
+// RecommendProjects scores open active projects against the user's roles and
+// skills. Projects the user authored or already responded to are skipped.
+func (r *Repo) RecommendProjects(ctx context.Context, user *models.User, limit int) ([]models.Recommendation, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	skills := user.Skills
+	if skills == nil {
+		skills = []string{}
+	}
+	skillsJSON, _ := json.Marshal(skills)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, slug, author_id, title, description, stack, status, is_closed, created_at, updated_at,
+		        MIN(role_matches, ?) * ?, MIN(stack_matches, ?) * ?, has_seats * ?, fresh
+		 FROM (SELECT p.*,
+		         (SELECT COUNT(*) FROM project_roles pr JOIN user_roles ur ON ur.role_id = pr.role_id
+		          WHERE pr.project_id = p.id AND ur.user_id = ?) AS role_matches,
+		         (SELECT COUNT(*) FROM json_each(p.stack) s
+		          WHERE s.value IN (SELECT value FROM json_each(?))) AS stack_matches,
+		         EXISTS (SELECT 1 FROM project_roles pr JOIN user_roles ur ON ur.role_id = pr.role_id
+		                 WHERE pr.project_id = p.id AND ur.user_id = ? AND pr.count > `+acceptedForRoleSQL+`) AS has_seats,
+		         CAST(ROUND(MAX(0, ? - (julianday('now') - julianday(p.created_at))) * ? / ?) AS INTEGER) AS fresh
+		       FROM projects p
+		       WHERE p.status = 'active' AND p.is_closed = 0 AND p.author_id != ?
+		         AND NOT EXISTS (SELECT 1 FROM responses r WHERE r.project_id = p.id AND r.user_id = ?))
+		 WHERE role_matches > 0 OR stack_matches > 0
+		 ORDER BY MIN(role_matches, ?) * ? + MIN(stack_matches, ?) * ? + has_seats * ? + fresh DESC, id DESC
+		 LIMIT ?`,
+		recMaxRoles, recRolePoints, recMaxStackTags, recStackPoints, recSeatsPoints,
+		user.ID, string(skillsJSON), user.ID,
+		recFreshDays, recFreshPoints, recFreshDays,
+		user.ID, user.ID,
+		recMaxRoles, recRolePoints, recMaxStackTags, recStackPoints, recSeatsPoints,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("recommend projects: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []models.Recommendation
+	for rows.Next() {
+		var rec models.Recommendation
+		p := &rec.Project
+		var stackJSON string
+		if err := rows.Scan(&p.ID, &p.Slug, &p.AuthorID, &p.Title, &p.Description, &stackJSON, &p.Status, &p.IsClosed, &p.CreatedAt, &p.UpdatedAt,
+			&rec.RoleScore, &rec.StackScore, &rec.SeatsScore, &rec.FreshScore); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(stackJSON), &p.Stack)
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("recommend projects: %w", err)
+	}
+
+	userRoles := make(map[int64]bool)
+	for _, role := range user.Roles {
+		userRoles[role.ID] = true
+	}
+	userSkills := make(map[string]bool)
+	for _, s := range user.Skills {
+		userSkills[s] = true
+	}
+
+	for i := range recs {
+		p := &recs[i].Project
+		roles, err := r.getProjectRoles(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		p.Roles = roles
+
+		author, err := r.GetUser(ctx, p.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		p.Author = author
+
+		for _, role := range roles {
+			if userRoles[role.ID] {
+				recs[i].MatchedRoles = append(recs[i].MatchedRoles, role)
+			}
+		}
+		for _, tag := range p.Stack {
+			if userSkills[tag] {
+				recs[i].MatchedStack = append(recs[i].MatchedStack, tag)
+			}
+		}
+	}
+
+	return recs, nil
+}
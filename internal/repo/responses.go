@@ -3,36 +3,143 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"svyaz/internal/models"
 )
 
-func (r *Repo) CreateResponse(ctx context.Context, projectID, userID int64, roleID *int64) error {
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO responses (project_id, user_id, role_id) VALUES (?, ?, ?)`,
-		projectID, userID, roleID,
-	)
+var (
+	// ErrInvalidTransition is returned when a response can't move to the
+	// requested status from the one it is in.
+	ErrInvalidTransition = errors.New("invalid response status transition")
+	// ErrRoleFull is returned when accepting a response would take its role
+	// past the number of people the project needs.
+	ErrRoleFull = errors.New("role is already filled")
+)
+
+// responseTransitions lists the statuses a response may move to from each
+// status. Rejected, withdrawn, left and removed responses are final.
+var responseTransitions = map[string][]string{
+	models.ResponsePending:     {models.ResponseShortlisted, models.ResponseInterview, models.ResponseAccepted, models.ResponseRejected, models.ResponseWithdrawn},
+	models.ResponseShortlisted: {models.ResponseInterview, models.ResponseAccepted, models.ResponseRejected, models.ResponseWithdrawn},
+	models.ResponseInterview:   {models.ResponseAccepted, models.ResponseRejected, models.ResponseWithdrawn},
+	models.ResponseAccepted:    {models.ResponseLeft, models.ResponseRemoved},
+}
+
+// CanTransition reports whether a response in status from may move to status to.
+func CanTransition(from, to string) bool {
+	for _, s := range responseTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinalResponse reports whether a response in status can't move on. The
+// applicant may respond again to replace it.
+func IsFinalResponse(status string) bool {
+	return len(responseTransitions[status]) == 0
+}
+
+// reopenResponse puts a response in a final status back to pending, for an
+// applicant who comes back to the role. It counts as a fresh response, so
+// its age for expiry starts over.
+func reopenResponse(ctx context.Context, tx *sql.Tx, responseID, actorID int64, from string) error {
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE responses SET status = 'pending', reject_reason = '', expiry_reminded = 0, created_at = CURRENT_TIMESTAMP
+		 WHERE id = ?`, responseID,
+	); err != nil {
+		return fmt.Errorf("reopen response: %w", err)
+	}
+	return recordResponseEvent(ctx, tx, responseID, actorID, from, models.ResponsePending)
+}
+
+// CreateResponse records a new pending response and returns its ID. If the
+// user's earlier response for the role reached a final status, that response
+// is reopened with the new message, links and answers instead. It returns
+// ErrInvalidTransition if the earlier response is still open.
+func (r *Repo) CreateResponse(ctx context.Context, projectID, userID int64, roleID *int64, message string, links []string, answers []models.Answer) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("create response: %w", err)
+		return 0, fmt.Errorf("begin create response: %w", err)
 	}
-	return nil
+	defer tx.Rollback()
+
+	var responseID int64
+	var from string
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, status FROM responses WHERE project_id = ? AND user_id = ? AND role_id IS ?`, projectID, userID, roleID,
+	).Scan(&responseID, &from)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO responses (project_id, user_id, role_id, message, links) VALUES (?, ?, ?, ?, ?)`,
+			projectID, userID, roleID, message, linksJSON(links),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("create response: %w", err)
+		}
+		responseID, _ = res.LastInsertId()
+
+		if err := recordResponseEvent(ctx, tx, responseID, userID, "", models.ResponsePending); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, fmt.Errorf("get earlier response: %w", err)
+	case !IsFinalResponse(from):
+		return 0, ErrInvalidTransition
+	default:
+		if err := reopenResponse(ctx, tx, responseID, userID, from); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE responses SET message = ?, links = ? WHERE id = ?`, message, linksJSON(links), responseID,
+		); err != nil {
+			return 0, fmt.Errorf("reopen response: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM response_answers WHERE response_id = ?`, responseID); err != nil {
+			return 0, fmt.Errorf("clear response answers: %w", err)
+		}
+	}
+
+	for _, a := range answers {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO response_answers (response_id, question_id, prompt, answer) VALUES (?, ?, ?, ?)`,
+			responseID, a.QuestionID, a.Prompt, a.Answer,
+		); err != nil {
+			return 0, fmt.Errorf("create response answer: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return responseID, nil
 }
 
 func (r *Repo) GetResponse(ctx context.Context, id int64) (*models.Response, error) {
 	resp := &models.Response{}
+	var links string
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, project_id, user_id, role_id, status, created_at FROM responses WHERE id = ?`, id,
-	).Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt)
+		`SELECT id, project_id, user_id, role_id, status, message, links, reject_reason, created_at FROM responses WHERE id = ?`, id,
+	).Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.Message, &links, &resp.RejectReason, &resp.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
+	_ = json.Unmarshal([]byte(links), &resp.Links)
 	return resp, nil
 }
 
-func (r *Repo) HasUserResponded(ctx context.Context, projectID, userID int64) (bool, error) {
+// HasUserResponded reports whether the user has a response for the project
+// role that is still open or accepted; a nil roleID means a response without
+// a role. Responses in a final status don't count, so the user can apply again.
+func (r *Repo) HasUserResponded(ctx context.Context, projectID, userID int64, roleID *int64) (bool, error) {
 	var exists int
 	err := r.db.QueryRowContext(ctx,
-		`SELECT 1 FROM responses WHERE project_id = ? AND user_id = ?`, projectID, userID,
+		`SELECT 1 FROM responses WHERE project_id = ? AND user_id = ? AND role_id IS ?
+		   AND status IN ('pending', 'shortlisted', 'interview', 'accepted')`, projectID, userID, roleID,
 	).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
@@ -40,24 +147,50 @@ func (r *Repo) HasUserResponded(ctx context.Context, projectID, userID int64) (b
 	return err == nil, err
 }
 
-func (r *Repo) GetUserResponseForProject(ctx context.Context, projectID, userID int64) (*models.Response, error) {
-	resp := &models.Response{}
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, project_id, user_id, role_id, status, created_at FROM responses WHERE project_id = ? AND user_id = ?`,
+// GetUserResponsesForProject returns the user's responses to a project, one
+// per role applied for.
+func (r *Repo) GetUserResponsesForProject(ctx context.Context, projectID, userID int64) ([]models.Response, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT resp.id, resp.project_id, resp.user_id, resp.role_id, resp.status, resp.message, resp.links, resp.reject_reason, resp.created_at,
+		        rl.id, rl.slug, rl.name
+		 FROM responses resp
+		 LEFT JOIN roles rl ON rl.id = resp.role_id
+		 WHERE resp.project_id = ? AND resp.user_id = ? ORDER BY resp.created_at, resp.id`,
 		projectID, userID,
-	).Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt)
+	)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get user responses for project: %w", err)
 	}
-	return resp, nil
+	defer rows.Close()
+
+	var responses []models.Response
+	for rows.Next() {
+		var resp models.Response
+		var links string
+		var roleID sql.NullInt64
+		var roleSlug, roleName sql.NullString
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.Message, &links, &resp.RejectReason, &resp.CreatedAt,
+			&roleID, &roleSlug, &roleName); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(links), &resp.Links)
+		if roleID.Valid {
+			resp.Role = &models.Role{ID: roleID.Int64, Slug: roleSlug.String, Name: roleName.String}
+		}
+		responses = append(responses, resp)
+	}
+	return responses, rows.Err()
 }
 
-func (r *Repo) DeleteResponse(ctx context.Context, id, userID int64) error {
+// UpdateResponseMessage lets the applicant rewrite their message and links
+// while the author hasn't decided on the response yet.
+func (r *Repo) UpdateResponseMessage(ctx context.Context, id, userID int64, message string, links []string) error {
 	result, err := r.db.ExecContext(ctx,
-		`DELETE FROM responses WHERE id = ? AND user_id = ?`, id, userID,
+		`UPDATE responses SET message = ?, links = ? WHERE id = ? AND user_id = ? AND status = 'pending'`,
+		message, linksJSON(links), id, userID,
 	)
 	if err != nil {
-		return fmt.Errorf("delete response: %w", err)
+		return fmt.Errorf("update response message: %w", err)
 	}
 	n, _ := result.RowsAffected()
 	if n == 0 {
@@ -66,14 +199,197 @@ func (r *Repo) DeleteResponse(ctx context.Context, id, userID int64) error {
 	return nil
 }
 
-func (r *Repo) UpdateResponseStatus(ctx context.Context, id int64, status string) error {
-	_, err := r.db.ExecContext(ctx, `UPDATE responses SET status = ? WHERE id = ?`, status, id)
-	return err
+// UpdateResponseStatus moves a response along the pipeline on behalf of
+// actorID and records the step in its history. It returns
+// ErrInvalidTransition if the response can't reach status from where it is.
+func (r *Repo) UpdateResponseStatus(ctx context.Context, id, actorID int64, status string) error {
+	return r.updateResponseStatus(ctx, id, actorID, status, "")
+}
+
+// RejectResponse rejects a response with an optional reason shown to the
+// applicant.
+func (r *Repo) RejectResponse(ctx context.Context, id, actorID int64, reason string) error {
+	return r.updateResponseStatus(ctx, id, actorID, models.ResponseRejected, reason)
+}
+
+// RemoveMember takes an accepted member off the team, freeing their seat,
+// with an optional reason shown to them.
+func (r *Repo) RemoveMember(ctx context.Context, id, actorID int64, reason string) error {
+	return r.updateResponseStatus(ctx, id, actorID, models.ResponseRemoved, reason)
+}
+
+func (r *Repo) updateResponseStatus(ctx context.Context, id, actorID int64, status, reason string) error {
+	var from []any
+	for s := range responseTransitions {
+		if CanTransition(s, status) {
+			from = append(from, s)
+		}
+	}
+	if len(from) == 0 {
+		return ErrInvalidTransition
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin update response status: %w", err)
+	}
+	defer tx.Rollback()
+
+	args := append([]any{actorID, status, id}, from...)
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO response_events (response_id, actor_id, from_status, to_status)
+		 SELECT id, ?, status, ? FROM responses WHERE id = ? AND status IN (`+placeholders(len(from))+`)`, args...,
+	)
+	if err != nil {
+		return fmt.Errorf("record response event: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvalidTransition
+	}
+
+	var projectID, userID int64
+	var roleID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT project_id, user_id, role_id FROM responses WHERE id = ?`, id).
+		Scan(&projectID, &userID, &roleID)
+	if err != nil {
+		return fmt.Errorf("get response: %w", err)
+	}
+
+	if status == models.ResponseAccepted && roleID.Valid {
+		if err := checkRoleCapacity(ctx, tx, projectID, roleID.Int64, userID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE responses SET status = ?, reject_reason = ? WHERE id = ?`, status, reason, id); err != nil {
+		return fmt.Errorf("update response status: %w", err)
+	}
+
+	if status == models.ResponseLeft || status == models.ResponseRemoved {
+		// Maintainers are picked from the team, so leaving it takes their
+		// rights away unless they still hold another role.
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM project_members
+			 WHERE project_id = ? AND user_id = ? AND role = 'maintainer'
+			   AND NOT EXISTS (SELECT 1 FROM responses WHERE project_id = ? AND user_id = ? AND status = 'accepted')`,
+			projectID, userID, projectID, userID,
+		); err != nil {
+			return fmt.Errorf("remove project maintainer: %w", err)
+		}
+	}
+
+	if status == models.ResponseAccepted {
+		if err := closeFilledProject(ctx, tx, projectID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkRoleCapacity returns ErrRoleFull if the role has no seat left for
+// userID. It runs inside the accepting transaction, after its first write, so
+// concurrent accepts for the same role are serialized by the write lock.
+func checkRoleCapacity(ctx context.Context, tx *sql.Tx, projectID, roleID, userID int64) error {
+	var count, filled int
+	err := tx.QueryRowContext(ctx,
+		`SELECT pr.count, (SELECT COUNT(*) FROM responses
+		   WHERE project_id = pr.project_id AND role_id = pr.role_id
+		     AND status = 'accepted' AND user_id != ?)
+		 FROM project_roles pr WHERE pr.project_id = ? AND pr.role_id = ?`,
+		userID, projectID, roleID,
+	).Scan(&count, &filled)
+	if err == sql.ErrNoRows {
+		// The role was removed from the project; there is nothing to fill.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check role capacity: %w", err)
+	}
+	if filled >= count {
+		return ErrRoleFull
+	}
+	return nil
+}
+
+// closeFilledProject closes recruiting on an auto-closing project once every
+// one of its roles is filled.
+func closeFilledProject(ctx context.Context, tx *sql.Tx, projectID int64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE projects SET is_closed = 1
+		 WHERE id = ? AND auto_close = 1 AND is_closed = 0
+		   AND EXISTS (SELECT 1 FROM project_roles pr WHERE pr.project_id = projects.id)
+		   AND NOT EXISTS (SELECT 1 FROM project_roles pr
+		       WHERE pr.project_id = projects.id AND pr.count > `+acceptedForRoleSQL+`)`,
+		projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("auto-close project: %w", err)
+	}
+	return nil
+}
+
+// ListOpenApplicants returns the users whose responses for a project role are
+// still being considered.
+func (r *Repo) ListOpenApplicants(ctx context.Context, projectID, roleID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id FROM responses
+		 WHERE project_id = ? AND role_id = ? AND status IN (?, ?, ?)`,
+		projectID, roleID, models.ResponsePending, models.ResponseShortlisted, models.ResponseInterview,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list open applicants: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func recordResponseEvent(ctx context.Context, tx *sql.Tx, responseID, actorID int64, from, to string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO response_events (response_id, actor_id, from_status, to_status) VALUES (?, ?, ?, ?)`,
+		responseID, actorID, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("record response event: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) listResponseEvents(ctx context.Context, responseID int64) ([]models.ResponseEvent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT e.id, e.response_id, e.actor_id, COALESCE(u.name, ''), e.from_status, e.to_status, e.created_at
+		 FROM response_events e
+		 LEFT JOIN users u ON u.id = e.actor_id
+		 WHERE e.response_id = ? ORDER BY e.id`, responseID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list response events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ResponseEvent
+	for rows.Next() {
+		var e models.ResponseEvent
+		if err := rows.Scan(&e.ID, &e.ResponseID, &e.ActorID, &e.ActorName, &e.FromStatus, &e.ToStatus, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
 }
 
 func (r *Repo) ListProjectResponses(ctx context.Context, projectID int64) ([]models.Response, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT resp.id, resp.project_id, resp.user_id, resp.role_id, resp.status, resp.created_at,
+		`SELECT resp.id, resp.project_id, resp.user_id, resp.role_id, resp.status, resp.message, resp.links, resp.reject_reason, resp.created_at,
 		        rl.id, rl.slug, rl.name
 		 FROM responses resp
 		 LEFT JOIN roles rl ON rl.id = resp.role_id
@@ -88,12 +404,14 @@ func (r *Repo) ListProjectResponses(ctx context.Context, projectID int64) ([]mod
 	var responses []models.Response
 	for rows.Next() {
 		var resp models.Response
+		var links string
 		var roleID sql.NullInt64
 		var roleSlug, roleName sql.NullString
-		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt,
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.Message, &links, &resp.RejectReason, &resp.CreatedAt,
 			&roleID, &roleSlug, &roleName); err != nil {
 			return nil, err
 		}
+		_ = json.Unmarshal([]byte(links), &resp.Links)
 		if roleID.Valid {
 			resp.Role = &models.Role{ID: roleID.Int64, Slug: roleSlug.String, Name: roleName.String}
 		}
@@ -102,14 +420,69 @@ func (r *Repo) ListProjectResponses(ctx context.Context, projectID int64) ([]mod
 			return nil, err
 		}
 		resp.User = user
+		answers, err := r.getResponseAnswers(ctx, resp.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Answers = answers
+		events, err := r.listResponseEvents(ctx, resp.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Events = events
 		responses = append(responses, resp)
 	}
 	return responses, nil
 }
 
+// ListProjectTeam returns the project's current members: its accepted
+// responses with their users and roles, ordered by role.
+func (r *Repo) ListProjectTeam(ctx context.Context, projectID int64) ([]models.Response, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT resp.id, resp.project_id, resp.user_id, resp.role_id, resp.status, resp.created_at,
+		        rl.id, rl.slug, rl.name
+		 FROM responses resp
+		 LEFT JOIN roles rl ON rl.id = resp.role_id
+		 WHERE resp.project_id = ? AND resp.status = 'accepted'
+		 ORDER BY rl.id IS NULL, rl.id, resp.created_at`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list project team: %w", err)
+	}
+	defer rows.Close()
+
+	var team []models.Response
+	for rows.Next() {
+		var resp models.Response
+		var roleID sql.NullInt64
+		var roleSlug, roleName sql.NullString
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt,
+			&roleID, &roleSlug, &roleName); err != nil {
+			return nil, err
+		}
+		if roleID.Valid {
+			resp.Role = &models.Role{ID: roleID.Int64, Slug: roleSlug.String, Name: roleName.String}
+		}
+		team = append(team, resp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range team {
+		user, err := r.GetUser(ctx, team[i].UserID)
+		if err != nil {
+			return nil, err
+		}
+		team[i].User = user
+	}
+	return team, nil
+}
+
 func (r *Repo) ListUserResponses(ctx context.Context, userID int64) ([]models.Response, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT r.id, r.project_id, r.user_id, r.role_id, r.status, r.created_at
+		`SELECT r.id, r.project_id, r.user_id, r.role_id, r.status, r.message, r.links, r.reject_reason, r.created_at
 		 FROM responses r WHERE r.user_id = ? ORDER BY r.created_at DESC`, userID,
 	)
 	if err != nil {
@@ -120,14 +493,56 @@ func (r *Repo) ListUserResponses(ctx context.Context, userID int64) ([]models.Re
 	var responses []models.Response
 	for rows.Next() {
 		var resp models.Response
-		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt); err != nil {
+		var links string
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.Message, &links, &resp.RejectReason, &resp.CreatedAt); err != nil {
 			return nil, err
 		}
+		_ = json.Unmarshal([]byte(links), &resp.Links)
 		project, err := r.GetProject(ctx, resp.ProjectID)
 		if err != nil {
 			return nil, err
 		}
 		resp.Project = project
+		events, err := r.listResponseEvents(ctx, resp.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Events = events
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// ListIncomingResponses returns the latest responses to projects the user
+// owns or maintains, newest first, with their history.
+func (r *Repo) ListIncomingResponses(ctx context.Context, userID int64, limit int) ([]models.Response, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.id, r.project_id, r.user_id, r.role_id, r.status, r.message, r.links, r.reject_reason, r.created_at
+		 FROM responses r JOIN project_members m ON m.project_id = r.project_id
+		 WHERE m.user_id = ? ORDER BY r.created_at DESC, r.id DESC LIMIT ?`, userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list incoming responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []models.Response
+	for rows.Next() {
+		var resp models.Response
+		var links string
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.Message, &links, &resp.RejectReason, &resp.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(links), &resp.Links)
+		if resp.Project, err = r.GetProject(ctx, resp.ProjectID); err != nil {
+			return nil, err
+		}
+		if resp.User, err = r.GetUser(ctx, resp.UserID); err != nil {
+			return nil, err
+		}
+		if resp.Events, err = r.listResponseEvents(ctx, resp.ID); err != nil {
+			return nil, err
+		}
 		responses = append(responses, resp)
 	}
 	return responses, nil
@@ -155,3 +570,88 @@ func (r *Repo) CountAcceptedPerRole(ctx context.Context, projectID int64) (map[i
 	}
 	return counts, nil
 }
+
+// staleResponsesCond matches pending responses that have gone unanswered for
+// more than the number of days bound to it, or whose project has stopped
+// recruiting.
+const staleResponsesCond = `status = 'pending' AND (
+	created_at <= datetime('now', '-' || ? || ' days')
+	OR project_id IN (SELECT id FROM projects WHERE is_closed = 1))`
+
+// ExpireResponses moves stale pending responses to expired and returns them.
+// The events have no actor: nobody made the decision.
+func (r *Repo) ExpireResponses(ctx context.Context, days int) ([]models.Response, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin expire responses: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO response_events (response_id, from_status, to_status)
+		 SELECT id, status, 'expired' FROM responses WHERE `+staleResponsesCond, days,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("record response events: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`UPDATE responses SET status = 'expired' WHERE `+staleResponsesCond+`
+		 RETURNING id, project_id, user_id, role_id, status, created_at`, days,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expire responses: %w", err)
+	}
+	responses, err := scanShortResponses(rows)
+	if err != nil {
+		return nil, fmt.Errorf("expire responses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit expire responses: %w", err)
+	}
+	return responses, nil
+}
+
+// TakeExpiringResponses returns pending responses that will expire within
+// the next remindDays days and haven't been reminded about yet, marking them
+// as reminded so the author hears about each response once.
+func (r *Repo) TakeExpiringResponses(ctx context.Context, days, remindDays int) ([]models.Response, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE responses SET expiry_reminded = 1
+		 WHERE status = 'pending' AND expiry_reminded = 0
+		   AND created_at <= datetime('now', '-' || ? || ' days')
+		   AND project_id IN (SELECT id FROM projects WHERE is_closed = 0)
+		 RETURNING id, project_id, user_id, role_id, status, created_at`, days-remindDays,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("take expiring responses: %w", err)
+	}
+	responses, err := scanShortResponses(rows)
+	if err != nil {
+		return nil, fmt.Errorf("take expiring responses: %w", err)
+	}
+	return responses, nil
+}
+
+func scanShortResponses(rows *sql.Rows) ([]models.Response, error) {
+	defer rows.Close()
+
+	var responses []models.Response
+	for rows.Next() {
+		var resp models.Response
+		if err := rows.Scan(&resp.ID, &resp.ProjectID, &resp.UserID, &resp.RoleID, &resp.Status, &resp.CreatedAt); err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, rows.Err()
+}
+
+func linksJSON(links []string) string {
+	if links == nil {
+		links = []string{}
+	}
+	data, _ := json.Marshal(links)
+	return string(data)
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"svyaz/internal/models"
+)
+
+// MaxSavedSearches caps how many saved searches one user can keep.
+const MaxSavedSearches = 10
+
+func (r *Repo) CreateSavedSearch(ctx context.Context, userID int64, roleSlugs, stack []string, query string) error {
+	if roleSlugs == nil {
+		roleSlugs = []string{}
+	}
+	if stack == nil {
+		stack = []string{}
+	}
+	rolesJSON, _ := json.Marshal(roleSlugs)
+	stackJSON, _ := json.Marshal(stack)
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO saved_searches (user_id, roles, stack, query) VALUES (?, ?, ?, ?)`,
+		userID, string(rolesJSON), string(stackJSON), query,
+	)
+	if err != nil {
+		return fmt.Errorf("create saved search: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) DeleteSavedSearch(ctx context.Context, id, userID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM saved_searches WHERE id = ? AND user_id = ?`, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("delete saved search: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) CountSavedSearches(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM saved_searches WHERE user_id = ?`, userID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *Repo) ListSavedSearches(ctx context.Context, userID int64) ([]models.SavedSearch, error) {
+	return r.querySavedSearches(ctx,
+		`SELECT id, user_id, roles, stack, query, created_at FROM saved_searches
+		 WHERE user_id = ? ORDER BY created_at DESC, id DESC`, userID,
+	)
+}
+
+func (r *Repo) querySavedSearches(ctx context.Context, query string, args ...interface{}) ([]models.SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []models.SavedSearch
+	for rows.Next() {
+		var s models.SavedSearch
+		var rolesJSON, stackJSON string
+		if err := rows.Scan(&s.ID, &s.UserID, &rolesJSON, &stackJSON, &s.Query, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(rolesJSON), &s.RoleSlugs)
+		_ = json.Unmarshal([]byte(stackJSON), &s.Stack)
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+// MatchSavedSearches returns ids of users with a saved search matching the
+// project who have not been alerted about it yet. Each search is checked
+// with the feed's filter conditions, so matching follows the feed exactly.
+// The project author and users without a linked Telegram chat are skipped.
+func (r *Repo) MatchSavedSearches(ctx context.Context, project *models.Project) ([]int64, error) {
+	searches, err := r.querySavedSearches(ctx,
+		`SELECT s.id, s.user_id, s.roles, s.stack, s.query, s.created_at
+		 FROM saved_searches s JOIN users u ON u.id = s.user_id
+		 WHERE s.user_id != ? AND u.tg_chat_id > 0 AND u.is_banned = 0
+		   AND NOT EXISTS (SELECT 1 FROM saved_search_alerts a WHERE a.user_id = s.user_id AND a.project_id = ?)
+		 ORDER BY s.user_id, s.id`, project.AuthorID, project.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []int64
+	matched := make(map[int64]bool)
+	for _, s := range searches {
+		if matched[s.UserID] {
+			continue
+		}
+		ok, err := r.projectMatches(ctx, project.ID, ProjectFilter{
+			RoleSlugs: s.RoleSlugs,
+			Stack:     s.Stack,
+			Query:     s.Query,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("match saved search %d: %w", s.ID, err)
+		}
+		if ok {
+			matched[s.UserID] = true
+			userIDs = append(userIDs, s.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// projectMatches reports whether the project would be in the feed filtered by f.
+func (r *Repo) projectMatches(ctx context.Context, projectID int64, f ProjectFilter) (bool, error) {
+	conditions, args := f.whereMatching()
+	conditions = append(conditions, `p.id = ?`)
+	args = append(args, projectID)
+
+	var ok bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM projects p WHERE `+strings.Join(conditions, ` AND `)+`)`, args...,
+	).Scan(&ok)
+	return ok, err
+}
+
+// MarkSavedSearchAlerted records that the user was alerted about the project.
+// It reports false if an alert was already recorded, so concurrent approvals
+// never send the same alert twice.
+func (r *Repo) MarkSavedSearchAlerted(ctx context.Context, userID, projectID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO saved_search_alerts (user_id, project_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		userID, projectID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("mark saved search alerted: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
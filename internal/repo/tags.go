@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tagKey is the case- and whitespace-insensitive form tags and aliases are
+// looked up by. Lowercasing happens in Go because SQLite's lower() and
+// NOCASE only fold ASCII.
+func tagKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// lookupTag returns the canonical name for a tag or one of its aliases.
+func (r *Repo) lookupTag(ctx context.Context, key string) (string, error) {
+	var name string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT name FROM tags WHERE key = ?
+		 UNION ALL
+		 SELECT t.name FROM tag_aliases a JOIN tags t ON t.id = a.tag_id WHERE a.key = ?
+		 LIMIT 1`, key, key,
+	).Scan(&name)
+	return name, err
+}
+
+// NormalizeTags maps every tag to its canonical name and drops duplicates
+// while keeping the order. Tags that are not in the catalogue are kept as
+// typed but not added to it, so users can't fill autocomplete with anything
+// they like.
+func (r *Repo) NormalizeTags(ctx context.Context, names []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		key := tagKey(name)
+		if key == "" {
+			continue
+		}
+
+		canonical, err := r.lookupTag(ctx, key)
+		if err == sql.ErrNoRows {
+			canonical, err = name, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lookup tag: %w", err)
+		}
+
+		if k := tagKey(canonical); !seen[k] {
+			seen[k] = true
+			result = append(result, canonical)
+		}
+	}
+	return result, nil
+}
+
+// ResolveTag returns the canonical name for a tag without adding it to the
+// catalogue. Unknown tags come back unchanged with ok set to false.
+func (r *Repo) ResolveTag(ctx context.Context, name string) (canonical string, ok bool) {
+	name = strings.Join(strings.Fields(name), " ")
+	canonical, err := r.lookupTag(ctx, tagKey(name))
+	if err != nil {
+		return name, false
+	}
+	return canonical, true
+}
+
+// SearchTags returns canonical tag names whose name or alias starts with
+// prefix, most used first.
+func (r *Repo) SearchTags(ctx context.Context, prefix string, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(tagKey(prefix)) + "%"
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT t.name FROM tags t
+		 WHERE t.key LIKE ? ESCAPE '\' OR t.id IN (SELECT tag_id FROM tag_aliases WHERE key LIKE ? ESCAPE '\')
+		 ORDER BY (SELECT COUNT(*) FROM projects p, json_each(p.stack) s WHERE s.value = t.name)
+		        + (SELECT COUNT(*) FROM users u, json_each(u.skills) s WHERE s.value = t.name) DESC,
+		          length(t.name), t.name
+		 LIMIT ?`, pattern, pattern, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search tags: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// BackfillTags rewrites project stacks and user skills saved before the tag
+// catalogue existed so they only contain canonical names.
+func (r *Repo) BackfillTags(ctx context.Context) error {
+	tables := []struct{ table, column string }{
+		{"projects", "stack"},
+		{"users", "skills"},
+	}
+
+	for _, tc := range tables {
+		rows, err := r.db.QueryContext(ctx, `SELECT id, `+tc.column+` FROM `+tc.table)
+		if err != nil {
+			return fmt.Errorf("backfill tags query %s: %w", tc.table, err)
+		}
+
+		type row struct {
+			id   int64
+			tags []string
+			raw  string
+		}
+		var items []row
+		for rows.Next() {
+			var it row
+			if err := rows.Scan(&it.id, &it.raw); err != nil {
+				rows.Close()
+				return err
+			}
+			_ = json.Unmarshal([]byte(it.raw), &it.tags)
+			items = append(items, it)
+		}
+		rows.Close()
+
+		updated := 0
+		for _, it := range items {
+			tags, err := r.NormalizeTags(ctx, it.tags)
+			if err != nil {
+				return err
+			}
+			if tags == nil {
+				tags = []string{}
+			}
+			data, _ := json.Marshal(tags)
+			if string(data) == it.raw {
+				continue
+			}
+			if _, err := r.db.ExecContext(ctx,
+				`UPDATE `+tc.table+` SET `+tc.column+` = ? WHERE id = ?`, string(data), it.id,
+			); err != nil {
+				return fmt.Errorf("backfill tags for %s %d: %w", tc.table, it.id, err)
+			}
+			updated++
+		}
+
+		if updated > 0 {
+			fmt.Printf("Backfilled tags for %d %s\n", updated, tc.table)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"svyaz/internal/models"
 )
 
@@ -41,9 +42,9 @@ func (r *Repo) GetUser(ctx context.Context, id int64) (*models.User, error) {
 	u := &models.User{}
 	var skillsJSON string
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, tg_id, tg_username, name, bio, experience, skills, photo_url, tg_chat_id, onboarded, is_admin, is_banned, created_at, updated_at
+		`SELECT id, tg_id, tg_username, name, bio, experience, skills, photo_url, tg_chat_id, onboarded, is_admin, is_banned, is_public, feed_sort, tg_notify_rejected, created_at, updated_at
 		 FROM users WHERE id = ?`, id,
-	).Scan(&u.ID, &u.TgID, &u.TgUsername, &u.Name, &u.Bio, &u.Experience, &skillsJSON, &u.PhotoURL, &u.TgChatID, &u.Onboarded, &u.IsAdmin, &u.IsBanned, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.TgID, &u.TgUsername, &u.Name, &u.Bio, &u.Experience, &skillsJSON, &u.PhotoURL, &u.TgChatID, &u.Onboarded, &u.IsAdmin, &u.IsBanned, &u.IsPublic, &u.FeedSort, &u.NotifyRejected, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("get user: %w", err)
 	}
@@ -66,12 +67,12 @@ func (r *Repo) GetUserByTgID(ctx context.Context, tgID int64) (*models.User, err
 	return r.GetUser(ctx, id)
 }
 
-func (r *Repo) UpdateUserProfile(ctx context.Context, userID int64, name, bio, experience string, skills []string, roleIDs []int64) error {
+func (r *Repo) UpdateUserProfile(ctx context.Context, userID int64, name, bio, experience string, skills []string, roleIDs []int64, isPublic bool) error {
 	skillsJSON, _ := json.Marshal(skills)
 
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE users SET name = ?, bio = ?, experience = ?, skills = ?, onboarded = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
-		name, bio, experience, string(skillsJSON), userID,
+		`UPDATE users SET name = ?, bio = ?, experience = ?, skills = ?, is_public = ?, onboarded = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		name, bio, experience, string(skillsJSON), isPublic, userID,
 	)
 	if err != nil {
 		return fmt.Errorf("update profile: %w", err)
@@ -122,6 +123,20 @@ func (r *Repo) SetTgChatID(ctx context.Context, userID, chatID int64) error {
 	return err
 }
 
+func (r *Repo) SetFeedSort(ctx context.Context, userID int64, sort string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET feed_sort = ? WHERE id = ?`, sort, userID)
+	return err
+}
+
+func (r *Repo) SetNotifyRejected(ctx context.Context, userID int64, notify bool) error {
+	v := 0
+	if notify {
+		v = 1
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET tg_notify_rejected = ? WHERE id = ?`, v, userID)
+	return err
+}
+
 func (r *Repo) ListUsers(ctx context.Context) ([]models.User, error) {
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, name, tg_username, photo_url FROM users ORDER BY id`)
@@ -158,3 +173,134 @@ func (r *Repo) GetAllRoles(ctx context.Context) ([]models.Role, error) {
 	}
 	return roles, nil
 }
+
+// ListUsersWithSkill returns users listed in the people directory that have skill.
+func (r *Repo) ListUsersWithSkill(ctx context.Context, skill string, limit int) ([]models.User, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT u.id FROM users u
+		 WHERE `+discoverableSQL+`
+		   AND EXISTS (SELECT 1 FROM json_each(u.skills) s WHERE s.value = ?)
+		 ORDER BY u.updated_at DESC LIMIT ?`, skill, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list users with skill: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		u, err := r.GetUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// discoverableSQL keeps users u who opted into the people directory. Banned
+// and not yet onboarded users are never listed, whatever their setting.
+const discoverableSQL = `u.is_public = 1 AND u.onboarded = 1 AND u.is_banned = 0`
+
+// Experience levels a profile can have.
+var ExperienceLevels = []string{"junior", "middle", "senior"}
+
+type PeopleFilter struct {
+	RoleSlugs []string
+	// Skills keeps people listing any of these canonical tags.
+	Skills     []string
+	Experience []string
+	// After is the cursor returned with the previous page; empty for the first page.
+	After string
+	Limit int
+}
+
+// ListPeople returns a page of the people directory, newest members first,
+// and the cursor for the next page, which is empty when there are no more.
+func (r *Repo) ListPeople(ctx context.Context, f PeopleFilter) ([]models.User, string, error) {
+	conditions := []string{discoverableSQL}
+	var args []interface{}
+
+	if len(f.RoleSlugs) > 0 {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM user_roles ur JOIN roles rl ON rl.id = ur.role_id
+			WHERE ur.user_id = u.id AND rl.slug IN (`+placeholders(len(f.RoleSlugs))+`))`)
+		args = append(args, stringArgs(f.RoleSlugs)...)
+	}
+	if len(f.Skills) > 0 {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM json_each(u.skills) s
+			WHERE s.value IN (`+placeholders(len(f.Skills))+`))`)
+		args = append(args, stringArgs(f.Skills)...)
+	}
+	if len(f.Experience) > 0 {
+		conditions = append(conditions, `u.experience IN (`+placeholders(len(f.Experience))+`)`)
+		args = append(args, stringArgs(f.Experience)...)
+	}
+
+	if f.After != "" {
+		key, id, err := decodeCursor(f.After)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, `(CAST(u.created_at AS TEXT) < ? OR (CAST(u.created_at AS TEXT) = ? AND u.id < ?))`)
+		args = append(args, key, key, id)
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = 30
+	}
+	// Fetch one extra row to learn whether there is a next page.
+	args = append(args, f.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT u.id, CAST(u.created_at AS TEXT) FROM users u
+		 WHERE `+strings.Join(conditions, ` AND `)+`
+		 ORDER BY CAST(u.created_at AS TEXT) DESC, u.id DESC LIMIT ?`, args...,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("list people: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var keys []string
+	for rows.Next() {
+		var id int64
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, "", err
+		}
+		ids = append(ids, id)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list people: %w", err)
+	}
+
+	var next string
+	if len(ids) > f.Limit {
+		ids = ids[:f.Limit]
+		next = encodeCursor(keys[f.Limit-1], ids[f.Limit-1])
+	}
+
+	people := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		u, err := r.GetUser(ctx, id)
+		if err != nil {
+			return nil, "", err
+		}
+		people = append(people, *u)
+	}
+	return people, next, nil
+}
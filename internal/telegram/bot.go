@@ -0,0 +1,552 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"svyaz/internal/models"
+	"svyaz/internal/repo"
+)
+
+const siteURL = "https://svyaz.fitra.tech"
+
+// botListLimit caps how many projects or responses a command lists, so the
+// reply stays within one readable message.
+const botListLimit = 10
+
+// command is a bot command. Handlers get the message and whatever the user
+// typed after the command name.
+type command struct {
+	name        string
+	description string
+	handle      func(ctx context.Context, m *message, args string)
+}
+
+// ResponseDecider accepts or rejects a response on behalf of actor, who
+// pressed a button under a new response message. status is
+// models.ResponseAccepted or models.ResponseRejected. It returns the outcome
+// to show in the message, or, when ok is false, why nothing was done.
+type ResponseDecider func(ctx context.Context, actor *models.User, responseID int64, status string) (result string, ok bool)
+
+// Bot answers the commands users send to the bot. It reads and updates the
+// site's data through the repo, the same way the web handlers do.
+type Bot struct {
+	client   *Client
+	repo     *repo.Repo
+	decide   ResponseDecider
+	commands []command
+}
+
+func NewBot(client *Client, r *repo.Repo, decide ResponseDecider) *Bot {
+	b := &Bot{client: client, repo: r, decide: decide}
+	b.commands = []command{
+		{"start", "Подключить уведомления", b.handleStart},
+		{"projects", "Открытые проекты, можно указать роль", b.handleProjects},
+		{"my", "Мои проекты и новые отклики", b.handleMy},
+		{"responses", "Статус моих откликов", b.handleResponses},
+		{"stop", "Отключить уведомления", b.handleStop},
+		{"help", "Список команд", b.handleHelp},
+	}
+	return b
+}
+
+// RegisterCommands publishes the command list, so Telegram suggests the
+// commands in the chat's menu.
+func (b *Bot) RegisterCommands() error {
+	cmds := make([]botCommand, len(b.commands))
+	for i, c := range b.commands {
+		cmds[i] = botCommand{Command: c.name, Description: c.description}
+	}
+	return b.client.SetMyCommands(cmds)
+}
+
+// handleUpdate routes an update to its command. Polling and the webhook
+// share it, so the bot behaves the same in both modes.
+func (b *Bot) handleUpdate(ctx context.Context, u update) {
+	if u.CallbackQuery != nil {
+		b.handleCallback(ctx, u.CallbackQuery)
+		return
+	}
+
+	m := u.Message
+	if m == nil || m.From == nil || m.Chat == nil {
+		return
+	}
+
+	name, args, ok := parseCommand(m.Text)
+	if !ok {
+		b.client.SendMessage(m.Chat.ID, "Я понимаю только команды. /help — список команд.")
+		return
+	}
+	for _, c := range b.commands {
+		if c.name == name {
+			c.handle(ctx, m, args)
+			return
+		}
+	}
+	b.client.SendMessage(m.Chat.ID, "Не знаю такой команды. /help — список команд.")
+}
+
+// parseCommand splits "/name@bot args" into the command name and its
+// arguments. The @bot suffix Telegram adds in group chats is dropped.
+func parseCommand(text string) (name, args string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(text[1:], " ")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.ToLower(name), strings.TrimSpace(args), name != ""
+}
+
+// sender returns the site user who sent the message. If they never logged in
+// on the site, it tells them to and returns nil.
+func (b *Bot) sender(ctx context.Context, m *message) *models.User {
+	user, err := b.repo.GetUserByTgID(ctx, m.From.ID)
+	if err == sql.ErrNoRows {
+		b.client.SendMessage(m.Chat.ID, "Сначала войдите на сайт через Telegram:\n"+siteURL)
+		return nil
+	}
+	if err != nil {
+		log.Printf("bot: get user by tg_id=%d: %v", m.From.ID, err)
+		b.client.SendMessage(m.Chat.ID, "Что-то пошло не так, попробуйте позже.")
+		return nil
+	}
+	return user
+}
+
+func (b *Bot) handleStart(ctx context.Context, m *message, args string) {
+	if args != "" {
+		b.handleStartPayload(ctx, m, args)
+		return
+	}
+
+	user := b.sender(ctx, m)
+	if user == nil {
+		return
+	}
+	if err := b.repo.SetTgChatID(ctx, user.ID, m.Chat.ID); err != nil {
+		log.Printf("bot: set tg_chat_id: %v", err)
+		return
+	}
+	log.Printf("bot: linked tg_chat_id=%d for user %d", m.Chat.ID, user.ID)
+	b.client.SendMessage(m.Chat.ID, "Уведомления подключены! Теперь вы будете получать сообщения о новых откликах.\n/help — что ещё умеет бот.")
+}
+
+func (b *Bot) handleStop(ctx context.Context, m *message, args string) {
+	user := b.sender(ctx, m)
+	if user == nil {
+		return
+	}
+	if err := b.repo.SetTgChatID(ctx, user.ID, 0); err != nil {
+		log.Printf("bot: clear tg_chat_id: %v", err)
+		return
+	}
+	log.Printf("bot: unlinked tg_chat_id for user %d", user.ID)
+	b.client.SendMessage(m.Chat.ID, "Уведомления отключены. Чтобы включить их снова, отправьте /start.")
+}
+
+func (b *Bot) handleHelp(ctx context.Context, m *message, args string) {
+	var sb strings.Builder
+	sb.WriteString("Что умеет бот:\n")
+	for _, c := range b.commands {
+		fmt.Fprintf(&sb, "/%s — %s\n", c.name, c.description)
+	}
+	sb.WriteString("\nНапример: /projects backend")
+	b.client.SendMessage(m.Chat.ID, sb.String())
+}
+
+// handleProjects lists the newest projects that are still recruiting,
+// optionally only those looking for the role named in args.
+func (b *Bot) handleProjects(ctx context.Context, m *message, args string) {
+	filter := repo.ProjectFilter{OpenOnly: true, HasSeats: true, Limit: botListLimit}
+	if args != "" {
+		roles, err := b.repo.GetAllRoles(ctx)
+		if err != nil {
+			log.Printf("bot: get roles: %v", err)
+			return
+		}
+		role := findRole(roles, args)
+		if role == nil {
+			names := make([]string, len(roles))
+			for i, r := range roles {
+				names[i] = r.Slug
+			}
+			b.client.SendMessage(m.Chat.ID, fmt.Sprintf("Роль «%s» не найдена. Доступные роли: %s",
+				html.EscapeString(args), strings.Join(names, ", ")))
+			return
+		}
+		filter.RoleSlugs = []string{role.Slug}
+	}
+
+	projects, _, err := b.repo.ListProjects(ctx, filter)
+	if err != nil {
+		log.Printf("bot: list projects: %v", err)
+		return
+	}
+	if len(projects) == 0 {
+		b.client.SendMessage(m.Chat.ID, "Подходящих проектов сейчас нет. Загляните позже!")
+		return
+	}
+
+	var sb strings.Builder
+	for i := range projects {
+		summary, err := b.projectSummary(ctx, &projects[i])
+		if err != nil {
+			log.Printf("bot: get project roles: %v", err)
+			return
+		}
+		sb.WriteString(summary + "\n\n")
+	}
+	b.client.SendMessage(m.Chat.ID, strings.TrimSpace(sb.String()))
+}
+
+// projectSummary returns the project's title, the roles it still looks for
+// and a link to it.
+func (b *Bot) projectSummary(ctx context.Context, p *models.Project) (string, error) {
+	roles, err := b.repo.GetProjectRolesWithFilled(ctx, p.ID)
+	if err != nil {
+		return "", err
+	}
+	var open []string
+	for _, r := range roles {
+		if r.Count > r.Filled {
+			open = append(open, r.Name)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>%s</b>\n", html.EscapeString(p.Title))
+	if len(open) > 0 {
+		fmt.Fprintf(&sb, "Ищут: %s\n", html.EscapeString(strings.Join(open, ", ")))
+	}
+	fmt.Fprintf(&sb, "%s/project/%s", siteURL, p.Slug)
+	return sb.String(), nil
+}
+
+// findRole looks a role up by slug or name, ignoring case.
+func findRole(roles []models.Role, s string) *models.Role {
+	for i, r := range roles {
+		if strings.EqualFold(r.Slug, s) || strings.EqualFold(r.Name, s) {
+			return &roles[i]
+		}
+	}
+	return nil
+}
+
+// handleMy lists the projects the user runs with their pending responses.
+func (b *Bot) handleMy(ctx context.Context, m *message, args string) {
+	user := b.sender(ctx, m)
+	if user == nil {
+		return
+	}
+	projects, err := b.repo.ListUserProjects(ctx, user.ID)
+	if err != nil {
+		log.Printf("bot: list user projects: %v", err)
+		return
+	}
+	if len(projects) == 0 {
+		b.client.SendMessage(m.Chat.ID, "У вас пока нет проектов. Создать проект:\n"+siteURL+"/project/new")
+		return
+	}
+
+	var sb strings.Builder
+	for _, p := range projects {
+		pending, err := b.repo.CountPendingResponses(ctx, p.ID)
+		if err != nil {
+			log.Printf("bot: count pending responses: %v", err)
+			return
+		}
+		fmt.Fprintf(&sb, "<b>%s</b>", html.EscapeString(p.Title))
+		if p.IsClosed {
+			sb.WriteString(" (набор закрыт)")
+		}
+		if pending > 0 {
+			fmt.Fprintf(&sb, "\nНовых откликов: %d\n%s/project/%s/responses?status=pending\n\n", pending, siteURL, p.Slug)
+		} else {
+			fmt.Fprintf(&sb, "\nНовых откликов нет\n%s/project/%s\n\n", siteURL, p.Slug)
+		}
+	}
+	b.client.SendMessage(m.Chat.ID, strings.TrimSpace(sb.String()))
+}
+
+// handleResponses lists the user's latest applications and their status.
+func (b *Bot) handleResponses(ctx context.Context, m *message, args string) {
+	user := b.sender(ctx, m)
+	if user == nil {
+		return
+	}
+	responses, err := b.repo.ListUserResponses(ctx, user.ID)
+	if err != nil {
+		log.Printf("bot: list user responses: %v", err)
+		return
+	}
+	if len(responses) == 0 {
+		b.client.SendMessage(m.Chat.ID, "Вы ещё не откликались на проекты. /projects — открытые проекты.")
+		return
+	}
+
+	var sb strings.Builder
+	for i, resp := range responses {
+		if i == botListLimit {
+			fmt.Fprintf(&sb, "И ещё %d: %s/my/responses", len(responses)-botListLimit, siteURL)
+			break
+		}
+		fmt.Fprintf(&sb, "<b>%s</b>", html.EscapeString(resp.Project.Title))
+		if role := responseRole(resp); role != "" {
+			fmt.Fprintf(&sb, " — %s", html.EscapeString(role))
+		}
+		fmt.Fprintf(&sb, "\n%s\n\n", models.ResponseStatusText(resp.Status))
+	}
+	b.client.SendMessage(m.Chat.ID, strings.TrimSpace(sb.String()))
+}
+
+// responseRole returns the name of the role the response applies for, or an
+// empty string if it doesn't name one.
+func responseRole(resp models.Response) string {
+	if resp.RoleID == nil {
+		return ""
+	}
+	for _, r := range resp.Project.Roles {
+		if r.ID == *resp.RoleID {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// decisionPrefix starts the callback data of the buttons that accept or
+// reject a response: "response:<id>:<status>".
+const decisionPrefix = "response:"
+
+// ResponseDecisionKeyboard returns the buttons attached to a new response
+// message: accept, reject, and a link to openURL.
+func ResponseDecisionKeyboard(responseID int64, openURL string) InlineKeyboard {
+	data := func(status string) string {
+		return fmt.Sprintf("%s%d:%s", decisionPrefix, responseID, status)
+	}
+	return InlineKeyboard{
+		{
+			{Text: "Принять", CallbackData: data(models.ResponseAccepted)},
+			{Text: "Отклонить", CallbackData: data(models.ResponseRejected)},
+		},
+		{{Text: "Открыть", URL: openURL}},
+	}
+}
+
+// parseDecision reads the callback data of a ResponseDecisionKeyboard button.
+func parseDecision(data string) (responseID int64, status string, ok bool) {
+	rest, found := strings.CutPrefix(data, decisionPrefix)
+	if !found {
+		return 0, "", false
+	}
+	id, status, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, "", false
+	}
+	responseID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || (status != models.ResponseAccepted && status != models.ResponseRejected) {
+		return 0, "", false
+	}
+	return responseID, status, true
+}
+
+// handleCallback applies the decision behind a pressed button. The presser is
+// matched to a site user, and the decider checks they may manage the
+// project's responses. On success the message is edited to show the outcome
+// and only its link buttons are kept.
+func (b *Bot) handleCallback(ctx context.Context, cq *callbackQuery) {
+	if cq.From == nil || cq.Message == nil || cq.Message.Chat == nil {
+		b.client.AnswerCallbackQuery(cq.ID, "")
+		return
+	}
+	if token, found := strings.CutPrefix(cq.Data, loginPrefix); found {
+		b.confirmLogin(ctx, cq, token)
+		return
+	}
+
+	responseID, status, ok := parseDecision(cq.Data)
+	if !ok || b.decide == nil {
+		b.client.AnswerCallbackQuery(cq.ID, "Эта кнопка больше не работает.")
+		return
+	}
+
+	user, err := b.repo.GetUserByTgID(ctx, cq.From.ID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("bot: get user by tg_id=%d: %v", cq.From.ID, err)
+		}
+		b.client.AnswerCallbackQuery(cq.ID, "Сначала войдите на сайт через Telegram.")
+		return
+	}
+
+	result, ok := b.decide(ctx, user, responseID, status)
+	if !ok {
+		b.client.AnswerCallbackQuery(cq.ID, result)
+		return
+	}
+	b.client.AnswerCallbackQuery(cq.ID, "")
+
+	text := html.EscapeString(cq.Message.Text) + "\n\n<b>" + html.EscapeString(result) + "</b>"
+	b.client.EditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, text, linkButtons(cq.Message.ReplyMarkup))
+}
+
+// linkButtons keeps the buttons of markup that open a URL.
+func linkButtons(markup *inlineKeyboardMarkup) InlineKeyboard {
+	if markup == nil {
+		return nil
+	}
+	var kb InlineKeyboard
+	for _, row := range markup.InlineKeyboard {
+		var links []InlineButton
+		for _, btn := range row {
+			if btn.URL != "" {
+				links = append(links, btn)
+			}
+		}
+		if len(links) > 0 {
+			kb = append(kb, links)
+		}
+	}
+	return kb
+}
+
+// handleStartPayload acts on a /start payload from a StartLink link.
+func (b *Bot) handleStartPayload(ctx context.Context, m *message, payload string) {
+	action, arg, ok := parseStartPayload(b.client.token, payload)
+	if !ok {
+		b.client.SendMessage(m.Chat.ID, "Ссылка повреждена. /help — что умеет бот.")
+		return
+	}
+
+	switch action {
+	case StartProject:
+		b.startProject(ctx, m, arg)
+	case StartNotify:
+		b.startNotify(ctx, m, arg)
+	case StartLogin:
+		b.startLogin(ctx, m, arg)
+	default:
+		b.client.SendMessage(m.Chat.ID, "Ссылка устарела. /help — что умеет бот.")
+	}
+}
+
+// startProject shows a project someone shared a bot link to.
+func (b *Bot) startProject(ctx context.Context, m *message, arg string) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return
+	}
+	project, err := b.repo.GetProject(ctx, id)
+	if err != nil || project.Status != "active" {
+		b.client.SendMessage(m.Chat.ID, "Проект не найден или ещё не опубликован.")
+		return
+	}
+
+	summary, err := b.projectSummary(ctx, project)
+	if err != nil {
+		log.Printf("bot: get project roles: %v", err)
+		return
+	}
+	text := summary
+	if desc := []rune(project.Description); len(desc) > 300 {
+		text += "\n\n" + html.EscapeString(string(desc[:300])) + "..."
+	} else if len(desc) > 0 {
+		text += "\n\n" + html.EscapeString(project.Description)
+	}
+	if project.IsClosed {
+		text += "\n\nНабор в проект закрыт."
+	}
+	link := fmt.Sprintf("%s/project/%s", siteURL, project.Slug)
+	b.client.SendMessageWithKeyboard(m.Chat.ID, text, InlineKeyboard{{{Text: "Открыть проект", URL: link}}})
+}
+
+// startNotify links notifications for the account the site built the link
+// for. The link only works from that account's own Telegram.
+func (b *Bot) startNotify(ctx context.Context, m *message, arg string) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return
+	}
+	user, err := b.repo.GetUser(ctx, id)
+	if err != nil {
+		b.client.SendMessage(m.Chat.ID, "Аккаунт не найден.")
+		return
+	}
+	if user.TgID != m.From.ID {
+		b.client.SendMessage(m.Chat.ID, "Эта ссылка для другого аккаунта Telegram. Откройте её из того Telegram, через который входите на сайт.")
+		return
+	}
+	if err := b.repo.SetTgChatID(ctx, user.ID, m.Chat.ID); err != nil {
+		log.Printf("bot: set tg_chat_id: %v", err)
+		return
+	}
+	log.Printf("bot: linked tg_chat_id=%d for user %d", m.Chat.ID, user.ID)
+	b.client.SendMessage(m.Chat.ID, fmt.Sprintf("Уведомления для аккаунта <b>%s</b> подключены! Теперь вы будете получать сообщения о новых откликах.\n/help — что ещё умеет бот.",
+		html.EscapeString(user.Name)))
+}
+
+// loginPrefix starts the callback data of the button that confirms a site
+// login: "login:<token>".
+const loginPrefix = "login:"
+
+// startLogin asks the user to confirm a login started on the site. Whoever
+// presses the button logs in the browser that started it, and anyone can
+// start a login and send the link to someone else, so the message says where
+// and when it started: the user should only confirm a login they recognize.
+func (b *Bot) startLogin(ctx context.Context, m *message, token string) {
+	login, err := b.repo.GetBotLogin(ctx, token)
+	if err == repo.ErrBotLoginExpired {
+		b.client.SendMessage(m.Chat.ID, "Ссылка для входа устарела. Начните вход на сайте заново.")
+		return
+	}
+	if err != nil {
+		log.Printf("bot: get login: %v", err)
+		b.client.SendMessage(m.Chat.ID, "Что-то пошло не так, попробуйте позже.")
+		return
+	}
+
+	agent := []rune(login.UserAgent)
+	if len(agent) > 200 {
+		agent = append(agent[:200], '…')
+	}
+	text := fmt.Sprintf("Вход на svyaz.fitra.tech запрошен %s UTC\nIP: %s\nБраузер: %s\n\n"+
+		"Подтверждайте, только если это вы начали вход и узнаёте этот браузер. "+
+		"Если ссылку вам прислал кто-то другой, не нажимайте кнопку: он получит доступ к вашему аккаунту.",
+		login.CreatedAt.UTC().Format("02.01.2006 15:04"), html.EscapeString(login.IP), html.EscapeString(string(agent)))
+	b.client.SendMessageWithKeyboard(m.Chat.ID, text,
+		InlineKeyboard{{{Text: "Подтвердить вход", CallbackData: loginPrefix + token}}})
+}
+
+// confirmLogin confirms a pending site login for whoever pressed the button,
+// creating their account if this is their first visit.
+func (b *Bot) confirmLogin(ctx context.Context, cq *callbackQuery, token string) {
+	user, err := b.repo.GetUserByTgID(ctx, cq.From.ID)
+	if err == sql.ErrNoRows {
+		name := strings.TrimSpace(cq.From.FirstName + " " + cq.From.LastName)
+		user, _, err = b.repo.UpsertUser(ctx, cq.From.ID, cq.From.Username, name, "")
+	}
+	if err != nil {
+		log.Printf("bot: login user tg_id=%d: %v", cq.From.ID, err)
+		b.client.AnswerCallbackQuery(cq.ID, "Что-то пошло не так, попробуйте позже.")
+		return
+	}
+
+	ok, err := b.repo.ConfirmBotLogin(ctx, token, user.ID)
+	if err != nil {
+		log.Printf("bot: confirm login: %v", err)
+		b.client.AnswerCallbackQuery(cq.ID, "Что-то пошло не так, попробуйте позже.")
+		return
+	}
+	if !ok {
+		b.client.AnswerCallbackQuery(cq.ID, "Ссылка для входа устарела. Начните вход на сайте заново.")
+		return
+	}
+	b.client.AnswerCallbackQuery(cq.ID, "")
+	b.client.EditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, "Вход подтверждён. Вернитесь на сайт.", nil)
+}
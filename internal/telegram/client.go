@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -23,14 +24,25 @@ func NewClient(token string) *Client {
 }
 
 func (c *Client) SendMessage(chatID int64, text string) {
+	c.SendMessageWithKeyboard(chatID, text, nil)
+}
+
+// SendMessageWithKeyboard sends a message with inline buttons under it. A nil
+// keyboard sends a plain message.
+func (c *Client) SendMessageWithKeyboard(chatID int64, text string, kb InlineKeyboard) {
 	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", c.token)
 
-	resp, err := c.http.PostForm(endpoint, url.Values{
+	form := url.Values{
 		"chat_id":                  {fmt.Sprintf("%d", chatID)},
 		"text":                     {text},
 		"parse_mode":               {"HTML"},
 		"disable_web_page_preview": {"true"},
-	})
+	}
+	if err := setReplyMarkup(form, kb); err != nil {
+		log.Printf("telegram send: %v", err)
+		return
+	}
+	resp, err := c.http.PostForm(endpoint, form)
 	if err != nil {
 		log.Printf("telegram send: %v", err)
 		return
@@ -46,31 +58,123 @@ func (c *Client) SendMessage(chatID int64, text string) {
 	}
 }
 
+// EditMessageText replaces the text and buttons of a message the bot sent. A
+// nil keyboard removes the buttons.
+func (c *Client) EditMessageText(chatID, messageID int64, text string, kb InlineKeyboard) {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageText", c.token)
+
+	form := url.Values{
+		"chat_id":                  {fmt.Sprintf("%d", chatID)},
+		"message_id":               {fmt.Sprintf("%d", messageID)},
+		"text":                     {text},
+		"parse_mode":               {"HTML"},
+		"disable_web_page_preview": {"true"},
+	}
+	if err := setReplyMarkup(form, kb); err != nil {
+		log.Printf("telegram edit: %v", err)
+		return
+	}
+	resp, err := c.http.PostForm(endpoint, form)
+	if err != nil {
+		log.Printf("telegram edit: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Description string `json:"description"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		log.Printf("telegram edit error: %s", result.Description)
+	}
+}
+
+// AnswerCallbackQuery stops the spinner on the button the user pressed. A
+// non-empty text is shown to them as an alert.
+func (c *Client) AnswerCallbackQuery(queryID, text string) {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", c.token)
+
+	form := url.Values{"callback_query_id": {queryID}}
+	if text != "" {
+		form.Set("text", text)
+		form.Set("show_alert", "true")
+	}
+	resp, err := c.http.PostForm(endpoint, form)
+	if err != nil {
+		log.Printf("telegram answer callback: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// InlineButton is a button under a message. It either opens URL or sends
+// CallbackData back to the bot.
+type InlineButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// InlineKeyboard is rows of buttons under a message.
+type InlineKeyboard [][]InlineButton
+
+func setReplyMarkup(form url.Values, kb InlineKeyboard) error {
+	if len(kb) == 0 {
+		return nil
+	}
+	markup, err := json.Marshal(inlineKeyboardMarkup{InlineKeyboard: kb})
+	if err != nil {
+		return err
+	}
+	form.Set("reply_markup", string(markup))
+	return nil
+}
+
+type inlineKeyboardMarkup struct {
+	InlineKeyboard InlineKeyboard `json:"inline_keyboard"`
+}
+
 type update struct {
-	UpdateID int64 `json:"update_id"`
-	Message  *message `json:"message"`
+	UpdateID      int64          `json:"update_id"`
+	Message       *message       `json:"message"`
+	CallbackQuery *callbackQuery `json:"callback_query"`
 }
 
 type message struct {
-	Text string  `json:"text"`
-	From *tgUser `json:"from"`
-	Chat *tgChat `json:"chat"`
+	MessageID   int64                 `json:"message_id"`
+	Text        string                `json:"text"`
+	From        *tgUser               `json:"from"`
+	Chat        *tgChat               `json:"chat"`
+	ReplyMarkup *inlineKeyboardMarkup `json:"reply_markup"`
+}
+
+// callbackQuery is sent when someone presses an inline button; Message is the
+// message the button is under.
+type callbackQuery struct {
+	ID      string   `json:"id"`
+	From    *tgUser  `json:"from"`
+	Message *message `json:"message"`
+	Data    string   `json:"data"`
 }
 
 type tgUser struct {
-	ID int64 `json:"id"`
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
 }
 
 type tgChat struct {
 	ID int64 `json:"id"`
 }
 
-// StartHandler is called for each /start message with (tgUserID, chatID).
-type StartHandler func(tgUserID, chatID int64)
+// allowedUpdates are the kinds of updates the bot asks Telegram for.
+const allowedUpdates = `["message","callback_query"]`
 
-// StartPolling runs long polling for /start commands in a background goroutine.
+// StartPolling runs long polling for bot commands in a background goroutine.
 // It clears any existing webhook and polls getUpdates.
-func (c *Client) StartPolling(ctx context.Context, onStart StartHandler) {
+func (c *Client) StartPolling(ctx context.Context, bot *Bot) {
 	// Clear webhook so polling works
 	c.deleteWebhook()
 
@@ -92,17 +196,130 @@ func (c *Client) StartPolling(ctx context.Context, onStart StartHandler) {
 
 			for _, u := range updates {
 				offset = u.UpdateID + 1
-				if u.Message != nil && u.Message.Text == "/start" && u.Message.From != nil && u.Message.Chat != nil {
-					onStart(u.Message.From.ID, u.Message.Chat.ID)
-				}
+				bot.handleUpdate(ctx, u)
 			}
 		}
 	}()
 }
 
+// SetWebhook asks Telegram to push updates to webhookURL instead of waiting
+// for getUpdates. Telegram sends secret back in the
+// X-Telegram-Bot-Api-Secret-Token header of every request.
+func (c *Client) SetWebhook(webhookURL, secret string) error {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/setWebhook", c.token)
+
+	resp, err := c.http.PostForm(endpoint, url.Values{
+		"url":             {webhookURL},
+		"secret_token":    {secret},
+		"allowed_updates": {allowedUpdates},
+	})
+	if err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("setWebhook: %s", result.Description)
+	}
+	return nil
+}
+
+// maxUpdateSize caps the body of a webhook request.
+const maxUpdateSize = 1 << 20
+
+// WebhookHandler returns the HTTP handler for updates Telegram pushes to the
+// webhook. Requests without the secret set in SetWebhook are rejected.
+func (c *Client) WebhookHandler(secret string, bot *Bot) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		var u update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&u); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		bot.handleUpdate(r.Context(), u)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+type botCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// SetMyCommands replaces the command list Telegram shows in the bot's menu.
+func (c *Client) SetMyCommands(commands []botCommand) error {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/setMyCommands", c.token)
+
+	body, err := json.Marshal(commands)
+	if err != nil {
+		return fmt.Errorf("setMyCommands: %w", err)
+	}
+	resp, err := c.http.PostForm(endpoint, url.Values{"commands": {string(body)}})
+	if err != nil {
+		return fmt.Errorf("setMyCommands: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("setMyCommands: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("setMyCommands: %s", result.Description)
+	}
+	return nil
+}
+
+// SetMenuButton makes the bot's menu button open webAppURL as a Mini App.
+func (c *Client) SetMenuButton(text, webAppURL string) error {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/setChatMenuButton", c.token)
+
+	button, err := json.Marshal(map[string]any{
+		"type":    "web_app",
+		"text":    text,
+		"web_app": map[string]string{"url": webAppURL},
+	})
+	if err != nil {
+		return fmt.Errorf("setChatMenuButton: %w", err)
+	}
+	resp, err := c.http.PostForm(endpoint, url.Values{"menu_button": {string(button)}})
+	if err != nil {
+		return fmt.Errorf("setChatMenuButton: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("setChatMenuButton: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("setChatMenuButton: %s", result.Description)
+	}
+	return nil
+}
+
 func (c *Client) getUpdates(offset int64, timeout int) ([]update, error) {
-	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?offset=%d&timeout=%d&allowed_updates=[\"message\"]",
-		c.token, offset, timeout)
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?offset=%d&timeout=%d&allowed_updates=%s",
+		c.token, offset, timeout, url.QueryEscape(allowedUpdates))
 
 	resp, err := c.http.Get(endpoint)
 	if err != nil {
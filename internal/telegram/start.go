@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// Actions a /start payload can carry. Links to the bot built with StartLink
+// make it do one of these instead of the generic greeting.
+const (
+	// StartProject shows the project with the given ID.
+	StartProject = "p"
+	// StartNotify links notifications for the user with the given ID.
+	StartNotify = "n"
+	// StartLogin confirms the pending site login with the given token.
+	StartLogin = "l"
+)
+
+// startSigSize is how many bytes of the HMAC a payload keeps. Telegram
+// limits payloads to 64 characters, so the full MAC doesn't fit.
+const startSigSize = 12
+
+// StartLink returns a link that opens the bot and sends it /start with a
+// payload for action and arg. The payload is signed with the bot token, so
+// the bot only acts on links the site built. arg must consist of letters and
+// digits.
+func StartLink(botUsername, botToken, action, arg string) string {
+	payload := action + "-" + arg + "-" + signStart(botToken, action, arg)
+	return "https://t.me/" + botUsername + "?start=" + url.QueryEscape(payload)
+}
+
+func signStart(botToken, action, arg string) string {
+	mac := hmac.New(sha256.New, []byte(botToken))
+	mac.Write([]byte("start:" + action + ":" + arg))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:startSigSize])
+}
+
+// parseStartPayload checks the signature of a StartLink payload and returns
+// its action and argument.
+func parseStartPayload(botToken, payload string) (action, arg string, ok bool) {
+	parts := strings.SplitN(payload, "-", 3)
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	action, arg, sig := parts[0], parts[1], parts[2]
+	if !hmac.Equal([]byte(sig), []byte(signStart(botToken, action, arg))) {
+		return "", "", false
+	}
+	return action, arg, true
+}
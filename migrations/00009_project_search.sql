@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE VIRTUAL TABLE projects_fts USING fts5(
+    title,
+    description,
+    stack,
+    content = 'projects',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO projects_fts (projects_fts) VALUES ('rebuild');
+
+-- +goose StatementBegin
+CREATE TRIGGER projects_fts_insert AFTER INSERT ON projects BEGIN
+    INSERT INTO projects_fts (rowid, title, description, stack)
+    VALUES (new.id, new.title, new.description, new.stack);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER projects_fts_delete AFTER DELETE ON projects BEGIN
+    INSERT INTO projects_fts (projects_fts, rowid, title, description, stack)
+    VALUES ('delete', old.id, old.title, old.description, old.stack);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER projects_fts_update AFTER UPDATE OF title, description, stack ON projects BEGIN
+    INSERT INTO projects_fts (projects_fts, rowid, title, description, stack)
+    VALUES ('delete', old.id, old.title, old.description, old.stack);
+    INSERT INTO projects_fts (rowid, title, description, stack)
+    VALUES (new.id, new.title, new.description, new.stack);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS projects_fts_update;
+DROP TRIGGER IF EXISTS projects_fts_delete;
+DROP TRIGGER IF EXISTS projects_fts_insert;
+DROP TABLE IF EXISTS projects_fts;
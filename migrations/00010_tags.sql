@@ -0,0 +1,81 @@
+-- +goose Up
+CREATE TABLE tags (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT     NOT NULL,
+    key        TEXT     NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tag_aliases (
+    key    TEXT    PRIMARY KEY,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE
+);
+
+INSERT INTO tags (name, key) VALUES
+    ('Go',          'go'),
+    ('Python',      'python'),
+    ('JavaScript',  'javascript'),
+    ('TypeScript',  'typescript'),
+    ('Java',        'java'),
+    ('Kotlin',      'kotlin'),
+    ('Swift',       'swift'),
+    ('C#',          'c#'),
+    ('C++',         'c++'),
+    ('PHP',         'php'),
+    ('Rust',        'rust'),
+    ('Ruby',        'ruby'),
+    ('Dart',        'dart'),
+    ('Flutter',     'flutter'),
+    ('React',       'react'),
+    ('React Native','react native'),
+    ('Vue',         'vue'),
+    ('Angular',     'angular'),
+    ('Svelte',      'svelte'),
+    ('Next.js',     'next.js'),
+    ('Node.js',     'node.js'),
+    ('Django',      'django'),
+    ('FastAPI',     'fastapi'),
+    ('Spring',      'spring'),
+    ('.NET',        '.net'),
+    ('PostgreSQL',  'postgresql'),
+    ('MySQL',       'mysql'),
+    ('SQLite',      'sqlite'),
+    ('MongoDB',     'mongodb'),
+    ('Redis',       'redis'),
+    ('Docker',      'docker'),
+    ('Kubernetes',  'kubernetes'),
+    ('Figma',       'figma'),
+    ('1С',          '1с');
+
+INSERT INTO tag_aliases (key, tag_id)
+SELECT a.key, t.id FROM (
+              SELECT 'golang' AS key, 'go' AS tag
+    UNION ALL SELECT 'го',            'go'
+    UNION ALL SELECT 'py',            'python'
+    UNION ALL SELECT 'python3',       'python'
+    UNION ALL SELECT 'js',            'javascript'
+    UNION ALL SELECT 'ts',            'typescript'
+    UNION ALL SELECT 'csharp',        'c#'
+    UNION ALL SELECT 'c sharp',       'c#'
+    UNION ALL SELECT 'cpp',           'c++'
+    UNION ALL SELECT 'reactjs',       'react'
+    UNION ALL SELECT 'react.js',      'react'
+    UNION ALL SELECT 'vue.js',        'vue'
+    UNION ALL SELECT 'vuejs',         'vue'
+    UNION ALL SELECT 'nextjs',        'next.js'
+    UNION ALL SELECT 'next',          'next.js'
+    UNION ALL SELECT 'node',          'node.js'
+    UNION ALL SELECT 'nodejs',        'node.js'
+    UNION ALL SELECT 'spring boot',   'spring'
+    UNION ALL SELECT 'dotnet',        '.net'
+    UNION ALL SELECT 'postgres',      'postgresql'
+    UNION ALL SELECT 'psql',          'postgresql'
+    UNION ALL SELECT 'pg',            'postgresql'
+    UNION ALL SELECT 'mongo',         'mongodb'
+    UNION ALL SELECT 'k8s',           'kubernetes'
+    UNION ALL SELECT 'rn',            'react native'
+) a JOIN tags t ON t.key = a.tag;
+
+-- +goose Down
+DROP TABLE IF EXISTS tag_aliases;
+DROP TABLE IF EXISTS tags;
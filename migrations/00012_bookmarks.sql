@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id    INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER  NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE INDEX idx_bookmarks_project ON bookmarks(project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS bookmarks;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE saved_searches (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    roles      TEXT     NOT NULL DEFAULT '[]',
+    stack      TEXT     NOT NULL DEFAULT '[]',
+    query      TEXT     NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_saved_searches_user ON saved_searches(user_id);
+
+-- One row per project a user was alerted about, so a project matching
+-- several saved searches (or approved twice) alerts only once.
+CREATE TABLE saved_search_alerts (
+    user_id    INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER  NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, project_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS saved_search_alerts;
+DROP TABLE IF EXISTS saved_searches;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE invitations (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id   INTEGER  NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    role_id      INTEGER  NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    user_id      INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inviter_id   INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status       TEXT     NOT NULL DEFAULT 'pending',
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at   DATETIME NOT NULL,
+    responded_at DATETIME
+);
+
+-- A user has at most one open invitation per project.
+CREATE UNIQUE INDEX idx_invitations_pending ON invitations(project_id, user_id) WHERE status = 'pending';
+CREATE INDEX idx_invitations_user ON invitations(user_id, status);
+CREATE INDEX idx_invitations_inviter ON invitations(inviter_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS invitations;
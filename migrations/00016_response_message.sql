@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE responses ADD COLUMN message TEXT NOT NULL DEFAULT '';
+ALTER TABLE responses ADD COLUMN links TEXT NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE responses DROP COLUMN links;
+ALTER TABLE responses DROP COLUMN message;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE project_questions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL DEFAULT 0,
+    kind       TEXT    NOT NULL DEFAULT 'text',
+    prompt     TEXT    NOT NULL,
+    options    TEXT    NOT NULL DEFAULT '[]',
+    required   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_project_questions_project ON project_questions(project_id, position);
+
+-- Answers keep a copy of the prompt so they still make sense after the
+-- author edits or removes the question.
+CREATE TABLE response_answers (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    response_id INTEGER NOT NULL REFERENCES responses(id) ON DELETE CASCADE,
+    question_id INTEGER REFERENCES project_questions(id) ON DELETE SET NULL,
+    prompt      TEXT    NOT NULL,
+    answer      TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_response_answers_response ON response_answers(response_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS response_answers;
+DROP TABLE IF EXISTS project_questions;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE response_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    response_id INTEGER NOT NULL REFERENCES responses(id) ON DELETE CASCADE,
+    actor_id    INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    from_status TEXT    NOT NULL DEFAULT '',
+    to_status   TEXT    NOT NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_response_events_response ON response_events(response_id, id);
+
+-- Existing responses get a creation event, and decided ones a decision by the
+-- project author. When the decision was made wasn't tracked, so it is dated
+-- with the response itself.
+INSERT INTO response_events (response_id, actor_id, to_status, created_at)
+SELECT id, user_id, 'pending', created_at FROM responses;
+
+INSERT INTO response_events (response_id, actor_id, from_status, to_status, created_at)
+SELECT r.id, p.author_id, 'pending', r.status, r.created_at
+FROM responses r JOIN projects p ON p.id = r.project_id
+WHERE r.status != 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS response_events;
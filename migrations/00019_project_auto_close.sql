@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN auto_close INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN auto_close;
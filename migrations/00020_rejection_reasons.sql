@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE responses ADD COLUMN reject_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN reject_reasons TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE users ADD COLUMN tg_notify_rejected INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN tg_notify_rejected;
+ALTER TABLE projects DROP COLUMN reject_reasons;
+ALTER TABLE responses DROP COLUMN reject_reason;
@@ -0,0 +1,70 @@
+-- +goose Up
+-- Responses become unique per role instead of per project, so someone can
+-- apply to several roles of one project. SQLite can't alter a UNIQUE
+-- constraint, so the table is rebuilt. Dropping it would cascade to answers
+-- and history, so those are set aside first and restored afterwards.
+CREATE TABLE response_answers_backup AS SELECT * FROM response_answers;
+CREATE TABLE response_events_backup AS SELECT * FROM response_events;
+
+CREATE TABLE responses_new (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id    INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id       INTEGER REFERENCES roles(id),
+    status        TEXT    NOT NULL DEFAULT 'pending',
+    message       TEXT    NOT NULL DEFAULT '',
+    links         TEXT    NOT NULL DEFAULT '[]',
+    reject_reason TEXT    NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, user_id, role_id)
+);
+
+INSERT INTO responses_new (id, project_id, user_id, role_id, status, message, links, reject_reason, created_at)
+SELECT id, project_id, user_id, role_id, status, message, links, reject_reason, created_at FROM responses;
+
+DROP TABLE responses;
+ALTER TABLE responses_new RENAME TO responses;
+
+CREATE INDEX idx_responses_project ON responses(project_id);
+CREATE INDEX idx_responses_user ON responses(user_id);
+-- UNIQUE treats NULLs as distinct, so responses without a role need their own
+-- guard to stay one per project.
+CREATE UNIQUE INDEX idx_responses_no_role ON responses(project_id, user_id) WHERE role_id IS NULL;
+
+INSERT INTO response_answers SELECT * FROM response_answers_backup;
+INSERT INTO response_events SELECT * FROM response_events_backup;
+DROP TABLE response_answers_backup;
+DROP TABLE response_events_backup;
+
+-- +goose Down
+CREATE TABLE response_answers_backup AS SELECT * FROM response_answers;
+CREATE TABLE response_events_backup AS SELECT * FROM response_events;
+
+CREATE TABLE responses_old (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id    INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id       INTEGER REFERENCES roles(id),
+    status        TEXT    NOT NULL DEFAULT 'pending',
+    message       TEXT    NOT NULL DEFAULT '',
+    links         TEXT    NOT NULL DEFAULT '[]',
+    reject_reason TEXT    NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, user_id)
+);
+
+-- Only the earliest response per project survives going back.
+INSERT INTO responses_old (id, project_id, user_id, role_id, status, message, links, reject_reason, created_at)
+SELECT id, project_id, user_id, role_id, status, message, links, reject_reason, created_at FROM responses
+WHERE id IN (SELECT MIN(id) FROM responses GROUP BY project_id, user_id);
+
+DROP TABLE responses;
+ALTER TABLE responses_old RENAME TO responses;
+
+CREATE INDEX idx_responses_project ON responses(project_id);
+CREATE INDEX idx_responses_user ON responses(user_id);
+
+INSERT INTO response_answers SELECT * FROM response_answers_backup WHERE response_id IN (SELECT id FROM responses);
+INSERT INTO response_events SELECT * FROM response_events_backup WHERE response_id IN (SELECT id FROM responses);
+DROP TABLE response_answers_backup;
+DROP TABLE response_events_backup;
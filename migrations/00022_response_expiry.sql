@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE responses ADD COLUMN expiry_reminded INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_responses_status ON responses(status, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_responses_status;
+ALTER TABLE responses DROP COLUMN expiry_reminded;
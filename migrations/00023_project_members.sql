@@ -0,0 +1,19 @@
+-- +goose Up
+-- People who run a project. The owner is also kept in projects.author_id;
+-- maintainers can manage the project and its responses but not delete it or
+-- change who runs it.
+CREATE TABLE project_members (
+    project_id INTEGER  NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id    INTEGER  NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role       TEXT     NOT NULL DEFAULT 'maintainer',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX idx_project_members_user ON project_members(user_id);
+
+INSERT INTO project_members (project_id, user_id, role, created_at)
+SELECT id, author_id, 'owner', created_at FROM projects;
+
+-- +goose Down
+DROP TABLE IF EXISTS project_members;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Site logins confirmed in the bot. The site creates a row and sends the
+-- browser to the bot with its token; user_id is filled in once the person
+-- confirms there, and the browser then trades the row for a session. The
+-- browser's address and user agent are shown in the bot, so the person can
+-- tell whether the login they are confirming is their own.
+CREATE TABLE bot_logins (
+    token      TEXT     PRIMARY KEY,
+    user_id    INTEGER  REFERENCES users(id) ON DELETE CASCADE,
+    ip         TEXT     NOT NULL DEFAULT '',
+    user_agent TEXT     NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS bot_logins;
@@ -37,6 +37,12 @@ function getCSRF() {
     return meta ? meta.content : '';
 }
 
+function escapeHTML(s) {
+    const div = document.createElement('div');
+    div.textContent = s;
+    return div.innerHTML;
+}
+
 function loadNotifications() {
     const dd = document.getElementById('notifDropdown');
 
@@ -61,6 +67,57 @@ function loadNotifications() {
             } else if (n.Type === 'response_accepted') {
                 text = `Ваш отклик на «${p.project_title || 'проект'}» принят`;
                 link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'response_rejected') {
+                text = `Ваш отклик на «${p.project_title || 'проект'}» отклонён`;
+                if (p.reason) text += `: ${escapeHTML(p.reason)}`;
+                link = '/my/responses';
+            } else if (n.Type === 'response_expired') {
+                text = p.closed
+                    ? `Набор в «${p.project_title || 'проект'}» закрыт, ваш отклик больше не рассматривается`
+                    : `Отклик на «${p.project_title || 'проект'}» закрыт без ответа`;
+                link = '/my/responses';
+            } else if (n.Type === 'responses_expiring') {
+                text = `В «${p.project_title || 'проект'}» ${p.count} откл. без ответа — через ${p.days} дн. они закроются`;
+                link = '/project/' + (p.project_slug || p.project_id || '') + '/responses?status=pending';
+            } else if (n.Type === 'response_status') {
+                const status = p.status === 'interview' ? 'вас приглашают на собеседование' : 'вы в шорт-листе';
+                text = `Отклик на «${p.project_title || 'проект'}»: ${status}`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'member_left') {
+                text = `<strong>${p.user_name || 'Участник'}</strong> покинул(а) команду «${p.project_title || 'проект'}»`;
+                if (p.role_name) text += ` (${p.role_name})`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'member_removed') {
+                text = `Вас исключили из команды «${p.project_title || 'проект'}»`;
+                if (p.reason) text += `: ${escapeHTML(p.reason)}`;
+                link = '/my/responses';
+            } else if (n.Type === 'role_filled') {
+                text = `В «${p.project_title || 'проект'}» уже набрали людей на роль ${p.role_name || ''}`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'maintainer_added') {
+                text = `<strong>${p.user_name || 'Автор'}</strong> сделал(а) вас помощником в «${p.project_title || 'проект'}»`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'maintainer_removed') {
+                text = `Вы больше не помогаете управлять «${p.project_title || 'проект'}»`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'ownership_transferred') {
+                text = `<strong>${p.user_name || 'Автор'}</strong> передал(а) вам проект «${p.project_title || 'проект'}»`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'saved_project_closed') {
+                text = `В сохранённом проекте «${p.project_title || 'проект'}» закрыт набор`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'saved_project_deleted') {
+                text = `Сохранённый проект «${p.project_title || 'проект'}» удалён`;
+                link = '/my/saved';
+            } else if (n.Type === 'invitation') {
+                text = `<strong>${p.user_name || 'Автор'}</strong> приглашает вас в «${p.project_title || 'проект'}» на роль ${p.role_name || ''}`;
+                link = '/my/responses';
+            } else if (n.Type === 'invitation_accepted') {
+                text = `<strong>${p.user_name || 'Кто-то'}</strong> принял(а) приглашение в «${p.project_title || 'проект'}»`;
+                link = '/project/' + (p.project_slug || p.project_id || '');
+            } else if (n.Type === 'invitation_declined') {
+                text = `<strong>${p.user_name || 'Кто-то'}</strong> отклонил(а) приглашение в «${p.project_title || 'проект'}»`;
+                link = '/user/' + (p.user_id || '');
             }
 
             return `<a href="${link}" class="notif-item ${n.Read ? '' : 'unread'}">${text}</a>`;
@@ -125,3 +182,168 @@ document.addEventListener('DOMContentLoaded', () => {
         });
     });
 });
+
+// "Select all" checkbox on the responses management page
+document.addEventListener('DOMContentLoaded', () => {
+    document.querySelectorAll('input[data-select-all]').forEach(toggle => {
+        const boxes = toggle.form.querySelectorAll(`input[name="${toggle.dataset.selectAll}"]:not(:disabled)`);
+        toggle.addEventListener('change', () => {
+            boxes.forEach(b => { b.checked = toggle.checked; });
+        });
+    });
+});
+
+// Login through the bot: wait until the user confirms it in Telegram
+document.addEventListener('DOMContentLoaded', () => {
+    const page = document.querySelector('[data-bot-login-check]');
+    if (!page) return;
+    const status = page.querySelector('[data-bot-login-status]');
+
+    const timer = setInterval(() => {
+        fetch(page.dataset.botLoginCheck)
+        .then(r => r.json())
+        .then(res => {
+            if (res.ok) {
+                clearInterval(timer);
+                window.location.href = res.redirect || '/';
+            } else if (res.expired) {
+                clearInterval(timer);
+                status.innerHTML = 'Время на вход истекло. <a href="/auth/bot">Начать заново</a>';
+            }
+        })
+        .catch(() => {});
+    }, 2000);
+});
+
+// Telegram Mini App: log in with the data Telegram hands to the web app
+document.addEventListener('DOMContentLoaded', () => {
+    const page = document.querySelector('[data-webapp-login]');
+    if (!page) return;
+    const status = page.querySelector('[data-webapp-status]');
+    const tg = window.Telegram && window.Telegram.WebApp;
+    if (!tg || !tg.initData) {
+        status.textContent = 'Откройте эту страницу из бота в Telegram.';
+        return;
+    }
+    tg.ready();
+    tg.expand();
+
+    fetch('/auth/webapp', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+        body: new URLSearchParams({ init_data: tg.initData })
+    })
+    .then(r => r.ok ? r.json() : Promise.reject(r.status))
+    .then(res => { window.location.replace(res.redirect || '/'); })
+    .catch(() => {
+        status.textContent = 'Не удалось войти. Закройте приложение и откройте его снова.';
+    });
+});
+
+// Tag autocomplete for comma-separated stack/skills inputs
+document.addEventListener('DOMContentLoaded', () => {
+    document.querySelectorAll('input[data-tag-input]').forEach(input => {
+        const wrap = document.createElement('div');
+        wrap.className = 'tag-suggest-wrap';
+        input.parentNode.insertBefore(wrap, input);
+        wrap.appendChild(input);
+
+        const list = document.createElement('div');
+        list.className = 'tag-suggest';
+        wrap.appendChild(list);
+
+        let items = [];
+        let active = -1;
+        let timer = null;
+
+        const currentPart = () => {
+            const parts = input.value.split(',');
+            return parts[parts.length - 1].trim();
+        };
+
+        const close = () => {
+            list.classList.remove('open');
+            active = -1;
+        };
+
+        const pick = (tag) => {
+            const parts = input.value.split(',');
+            parts[parts.length - 1] = ' ' + tag;
+            input.value = parts.map(p => p.trim()).filter(Boolean).join(', ') + ', ';
+            close();
+            input.focus();
+        };
+
+        const render = () => {
+            list.innerHTML = '';
+            items.forEach((tag, i) => {
+                const btn = document.createElement('button');
+                btn.type = 'button';
+                btn.className = 'tag-suggest-item' + (i === active ? ' active' : '');
+                btn.textContent = tag;
+                btn.addEventListener('mousedown', (e) => {
+                    e.preventDefault();
+                    pick(tag);
+                });
+                list.appendChild(btn);
+            });
+            list.classList.toggle('open', items.length > 0);
+        };
+
+        input.addEventListener('input', () => {
+            clearTimeout(timer);
+            const q = currentPart();
+            if (!q) {
+                close();
+                return;
+            }
+            timer = setTimeout(() => {
+                fetch('/api/tags?q=' + encodeURIComponent(q))
+                    .then(r => r.json())
+                    .then(tags => {
+                        const taken = input.value.split(',').map(p => p.trim().toLowerCase());
+                        items = (tags || []).filter(t => !taken.slice(0, -1).includes(t.toLowerCase()));
+                        active = -1;
+                        render();
+                    })
+                    .catch(close);
+            }, 150);
+        });
+
+        input.addEventListener('keydown', (e) => {
+            if (!list.classList.contains('open')) return;
+            if (e.key === 'ArrowDown' || e.key === 'ArrowUp') {
+                e.preventDefault();
+                const step = e.key === 'ArrowDown' ? 1 : -1;
+                active = (active + step + items.length) % items.length;
+                render();
+            } else if (e.key === 'Enter' && active >= 0) {
+                e.preventDefault();
+                pick(items[active]);
+            } else if (e.key === 'Escape') {
+                close();
+            }
+        });
+
+        input.addEventListener('blur', close);
+    });
+});
+
+// Screening questions editor on the project form
+function addQuestion() {
+    const list = document.getElementById('questionList');
+    const tpl = document.getElementById('questionTemplate');
+    if (!list || !tpl) return;
+    if (list.querySelectorAll('.question-row').length >= 5) return;
+    list.appendChild(tpl.content.cloneNode(true));
+    if (window.lucide) lucide.createIcons();
+}
+
+function removeQuestion(btn) {
+    btn.closest('.question-row').remove();
+}
+
+function syncQuestionRow(select) {
+    const options = select.closest('.question-row').querySelector('.question-options');
+    options.hidden = select.value !== 'choice';
+}